@@ -0,0 +1,350 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/suggester"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	PROGRAM = "priceSuggester"
+
+	HELP       = "help"
+	COMPLETION = "completion"
+	// COMPLETE is the hidden command the completion scripts call.
+	COMPLETE = "__complete"
+
+	EXIT_FAILURE = 1
+	EXIT_USAGE   = 2
+)
+
+// errMissingArgs makes a command print its usage and exit with EXIT_USAGE.
+var errMissingArgs = errors.New("missing arguments")
+
+// command is a node of the command tree. Commands with subcommands only group
+// them, the others define their flags and return the function running them.
+type command struct {
+	name    string
+	args    string
+	summary string
+	hidden  bool
+	// rawArgs commands get every argument, including the ones starting with a dash.
+	rawArgs     bool
+	subcommands []*command
+	define      func(flags *flag.FlagSet) func(env *env, args []string) error
+}
+
+// exitError is an error the process exits with code for.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+// env loads the configuration and builds the suggester the first time a command needs them.
+type env struct {
+	configFile  string
+	globalFlags *flag.FlagSet
+	config      *suggester.Config
+	suggester   *suggester.Suggester
+}
+
+func (e *env) loadConfig() (suggester.Config, error) {
+	if e.config == nil {
+		config, err := suggester.LoadConfig(e.configFile)
+
+		if err == nil {
+			err = config.ApplyFlags(e.globalFlags)
+		}
+
+		if err != nil {
+			return config, &exitError{code: EXIT_USAGE, err: err}
+		}
+
+		e.config = &config
+	}
+
+	return *e.config, nil
+}
+
+func (e *env) loadSuggester() (*suggester.Suggester, error) {
+	if e.suggester == nil {
+		config, err := e.loadConfig()
+
+		if err != nil {
+			return nil, err
+		}
+
+		storage, err := suggester.NewStorage(config)
+
+		if err != nil {
+			return nil, &exitError{code: EXIT_USAGE, err: err}
+		}
+
+		e.suggester = suggester.NewSuggester(config, suggester.WithStorage(storage))
+	}
+
+	return e.suggester, nil
+}
+
+// loadModel returns the suggester with the model loaded, if it was trained.
+// A model that was trained but can not be loaded is an error.
+func (e *env) loadModel() (*suggester.Suggester, error) {
+	s, err := e.loadSuggester()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.LoadModel(); err != nil && !os.IsNotExist(err) {
+		config := s.Config()
+
+		path := config.DataTrainedPath
+		if config.Storage == suggester.STORAGE_BOLT {
+			path = config.BoltFile
+		}
+
+		return nil, errors.New(fmt.Sprintf("Error loading model: %s %s", path, err))
+	}
+
+	return s, nil
+}
+
+func (e *env) close() {
+	if e.suggester != nil {
+		e.suggester.Close()
+	}
+}
+
+func (c *command) subcommand(name string) *command {
+	for _, subcommand := range c.subcommands {
+		if subcommand.name == name {
+			return subcommand
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the command args lead to, its path and the remaining args.
+func (c *command) resolve(args []string) (*command, []string, []string) {
+	cmd := c
+	var path []string
+
+	for len(args) > 0 {
+		subcommand := cmd.subcommand(args[0])
+
+		if subcommand == nil {
+			break
+		}
+
+		cmd = subcommand
+		path = append(path, subcommand.name)
+		args = args[1:]
+	}
+
+	return cmd, path, args
+}
+
+// newFlags returns the flags of a leaf command and the function running it.
+func (c *command) newFlags(path []string, errorHandling flag.ErrorHandling) (*flag.FlagSet, func(env *env, args []string) error) {
+	flags := flag.NewFlagSet(strings.Join(append([]string{PROGRAM}, path...), " "), errorHandling)
+	run := c.define(flags)
+
+	flags.Usage = func() {
+		c.printHelp(flags.Output(), path, flags)
+	}
+
+	return flags, run
+}
+
+// run runs the command args lead to.
+func (c *command) run(env *env, args []string) error {
+	cmd, path, args := c.resolve(args)
+
+	if cmd.define == nil {
+		if len(args) > 0 && args[0] != "-h" && args[0] != "-help" && args[0] != "--help" {
+			cmd.printHelp(os.Stderr, path, nil)
+			return &exitError{code: EXIT_USAGE, err: errors.New(fmt.Sprintf("Unknown command: %s", strings.Join(append(path, args[0]), " ")))}
+		}
+
+		cmd.printHelp(os.Stdout, path, nil)
+		return nil
+	}
+
+	flags, run := cmd.newFlags(path, flag.ExitOnError)
+
+	if !cmd.rawArgs {
+		flags.Parse(args)
+		args = flags.Args()
+	}
+
+	err := run(env, args)
+
+	if err == errMissingArgs {
+		flags.Usage()
+		return &exitError{code: EXIT_USAGE, err: errors.New(fmt.Sprintf("Usage: %s", cmd.usage(path)))}
+	}
+
+	return err
+}
+
+func (c *command) usage(path []string) string {
+	usage := append([]string{PROGRAM, "[config flags]"}, path...)
+
+	if c.define == nil {
+		usage = append(usage, "<command>")
+	} else {
+		usage = append(usage, "[flags]")
+	}
+
+	if c.args != "" {
+		usage = append(usage, c.args)
+	}
+
+	return strings.Join(usage, " ")
+}
+
+// printHelp prints the usage of the command, its subcommands and its flags.
+func (c *command) printHelp(w io.Writer, path []string, flags *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: %s\n\n", c.usage(path))
+
+	if c.summary != "" {
+		fmt.Fprintf(w, "%s\n\n", c.summary)
+	}
+
+	if len(c.subcommands) > 0 {
+		fmt.Fprintln(w, "Commands:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		c.printCommands(tw, nil)
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+
+	if flags != nil {
+		fmt.Fprintln(w, "Flags:")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+		fmt.Fprintln(w)
+	}
+
+	if len(path) == 0 {
+		fmt.Fprint(w, rootHelp)
+	} else {
+		fmt.Fprintf(w, "Run '%s help' for the config flags.\n", PROGRAM)
+	}
+}
+
+func (c *command) printCommands(w io.Writer, path []string) {
+	for _, subcommand := range c.subcommands {
+		if subcommand.hidden {
+			continue
+		}
+
+		subcommandPath := append(append([]string{}, path...), subcommand.name)
+
+		if subcommand.define != nil || subcommand.summary != "" {
+			fmt.Fprintf(w, "  %s\t%s\n", strings.Join(subcommandPath, " "), subcommand.summary)
+		}
+
+		subcommand.printCommands(w, subcommandPath)
+	}
+}
+
+// commandTree returns the commands of the CLI. The names of the commands are
+// the ones in the suggester package, as FETCH_DATA_SET.
+func commandTree() *command {
+	root := &command{
+		summary: "priceSuggester is a tool for suggest prices given a category Id.",
+		subcommands: []*command{
+			{name: suggester.FETCH_DATA_SET, args: "[category]", summary: "Fetch data set of items by categories.", define: fetch},
+			{name: suggester.TRAIN_MODEL, summary: "Train the data set.", define: train},
+			{name: suggester.SUGGEST, args: "<category>", summary: "Suggest a price given a category.", define: suggest},
+			{name: suggester.TREND, args: "<category>", summary: "Show the price series and monthly rate of change of a category.", define: trend},
+			{name: suggester.CLEAN, summary: "Clean data set and data trained folders.", define: clean},
+			{name: suggester.EVALUATE, summary: "Evaluate suggestions against a holdout of the data set.", define: evaluate},
+			{name: suggester.DIFF, args: "<old data trained file> <new data trained file>", summary: "Compare two data trained files.", define: diff},
+			{name: suggester.DATA_SET, subcommands: []*command{
+				{name: suggester.DATA_SET_CONVERT, args: "[category...]", summary: "Convert the data set to json or jsonl.gz format.", define: dataSetConvert},
+				{name: suggester.DATA_SET_QUERY, summary: "Print the items of a category snapshot within a price range.", define: dataSetQuery},
+				{name: suggester.DATA_SET_STATS, args: "[category...]", summary: "Show items, currencies, prices and sampling of the latest snapshots.", define: dataSetStats},
+				{name: suggester.DATA_SET_VALIDATE, args: "[category...]", summary: "Report unreadable files, empty pages and items of other categories.", define: dataSetValidate},
+			}},
+			{name: suggester.SERVE, summary: "Serve a http service, on 8080 port by default, until SIGTERM.", define: serve},
+			{name: suggester.CONFIG, subcommands: []*command{
+				{name: suggester.CONFIG_SHOW, summary: "Show the effective configuration.", define: configShow},
+			}},
+			{name: COMPLETION, args: "bash|zsh", summary: "Print the shell completion script.", define: completion},
+			{name: COMPLETE, hidden: true, rawArgs: true, define: complete},
+		},
+	}
+
+	root.subcommands = append(root.subcommands, &command{
+		name:    HELP,
+		args:    "[command]",
+		summary: "Help Meli Price Suggester, or a command.",
+		define: func(flags *flag.FlagSet) func(env *env, args []string) error {
+			return func(env *env, args []string) error {
+				cmd, path, _ := root.resolve(args)
+
+				var flags *flag.FlagSet
+				if cmd.define != nil {
+					flags, _ = cmd.newFlags(path, flag.ContinueOnError)
+				}
+
+				cmd.printHelp(os.Stdout, path, flags)
+				return nil
+			}
+		},
+	})
+
+	return root
+}
+
+const rootHelp = `Config flags, also set by env vars or a YAML/TOML file:
+
+  -config file, -data-set-path, -data-trained-path, -storage, -bolt-file,
+  -price-index-file, -data-set-format, -site, -endpoint, -attribution,
+  -sample-page-size, -sample-confidence, -sample-precision, -retry-max,
+  -retry-delay-ms, -server-address, -server-cert-file, -server-key-file,
+  -server-read-timeout-ms, -server-write-timeout-ms, -server-idle-timeout-ms,
+  -server-shutdown-grace-ms, -server-cache-control, -server-api-keys-file,
+  -server-rate-limit, -server-rate-burst
+
+Every command has --help. Reporting commands take --output text, table, json
+or csv. Commands exit with code 1 when they fail, and with code 2 on invalid
+flags, arguments or configuration.
+
+Examples:
+  priceSuggester fetch
+  priceSuggester fetch MLA1743
+  priceSuggester fetch --dedup MLA1743
+  priceSuggester fetch --format jsonl.gz
+  priceSuggester dataset convert --format jsonl.gz
+  priceSuggester dataset query --category MLA1743 --min-price 100 --max-price 500
+  priceSuggester dataset stats MLA1743
+  priceSuggester dataset validate --output json
+  priceSuggester train
+  priceSuggester train --category MLA1743
+  priceSuggester train --incremental
+  priceSuggester train --max-unreadable-files 0 --max-dropped-ratio 0.5
+  priceSuggester serve
+  priceSuggester suggest MLA70400
+  priceSuggester suggest -adjust index MLA70400
+  priceSuggester suggest --output json MLA70400 MLA1743
+  priceSuggester trend MLA70400
+  priceSuggester evaluate -holdout 0.2 -seed 1
+  priceSuggester diff -max-change 0.2 old.json ./datatrained/datatrained.json
+  priceSuggester -config suggester.yaml -server-address :9090 serve
+  priceSuggester -data-set-path /var/lib/suggester/dataset config show
+  priceSuggester help train
+  source <(priceSuggester completion bash)
+`
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// bashCompletion asks the program itself for the candidates of the current word.
+const bashCompletion = `# bash completion for priceSuggester
+_priceSuggester() {
+	local IFS=$'\n'
+	COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _priceSuggester priceSuggester
+`
+
+const zshCompletion = `#compdef priceSuggester
+autoload -U +X bashcompinit && bashcompinit
+` + bashCompletion
+
+func completion(flags *flag.FlagSet) func(env *env, args []string) error {
+	return func(env *env, args []string) error {
+		if len(args) != 1 {
+			return errMissingArgs
+		}
+
+		switch args[0] {
+		case "bash":
+			fmt.Print(bashCompletion)
+		case "zsh":
+			fmt.Print(zshCompletion)
+		default:
+			return &exitError{code: EXIT_USAGE, err: errors.New(fmt.Sprintf("Shell: %s is not supported.", args[0]))}
+		}
+
+		return nil
+	}
+}
+
+func complete(flags *flag.FlagSet) func(env *env, args []string) error {
+	return func(env *env, args []string) error {
+		for _, candidate := range completeWords(commandTree(), env.globalFlags, args) {
+			fmt.Println(candidate)
+		}
+		return nil
+	}
+}
+
+// completeWords returns the candidates of the last of words, the words typed
+// after the program name: flags of the command typed so far if it starts with
+// a dash, or its subcommands otherwise.
+func completeWords(root *command, globalFlags *flag.FlagSet, words []string) []string {
+	current := ""
+	if len(words) > 0 {
+		current = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	cmd := root
+	var path []string
+	flags := globalFlags
+
+	for index := 0; index < len(words); index++ {
+		word := words[index]
+
+		if strings.HasPrefix(word, "-") {
+			// Flags other than booleans take the next word unless given as -flag=value
+			name := strings.TrimLeft(word, "-")
+			if f := flags.Lookup(name); f != nil && !isBoolFlag(f) {
+				index++
+			}
+			continue
+		}
+
+		subcommand := cmd.subcommand(word)
+
+		if subcommand == nil {
+			continue
+		}
+
+		cmd = subcommand
+		path = append(path, subcommand.name)
+
+		if cmd.define != nil {
+			flags, _ = cmd.newFlags(path, flag.ContinueOnError)
+		}
+	}
+
+	var candidates []string
+
+	if strings.HasPrefix(current, "-") {
+		dashes := "-"
+		if strings.HasPrefix(current, "--") {
+			dashes = "--"
+		}
+
+		flags.VisitAll(func(f *flag.Flag) {
+			if strings.HasPrefix(dashes+f.Name, current) {
+				candidates = append(candidates, dashes+f.Name)
+			}
+		})
+	} else {
+		for _, subcommand := range cmd.subcommands {
+			if !subcommand.hidden && strings.HasPrefix(subcommand.name, current) {
+				candidates = append(candidates, subcommand.name)
+			}
+		}
+	}
+
+	sort.Strings(candidates)
+
+	return candidates
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface {
+		IsBoolFlag() bool
+	})
+
+	return ok && boolFlag.IsBoolFlag()
+}
@@ -1,95 +1,704 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"github.com/gin-gonic/gin"
-	"github.com/jesusfar/meli.price.suggester/meli"
 	"github.com/jesusfar/meli.price.suggester/suggester"
+	"io"
 	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
-func printHelp() {
-	fmt.Println(`
+func serve(flags *flag.FlagSet) func(env *env, args []string) error {
+	return func(env *env, args []string) error {
+		s, err := env.loadModel()
+		if err != nil {
+			return err
+		}
+
+		server, err := suggester.NewServer(s)
+		if err != nil {
+			return &exitError{code: EXIT_USAGE, err: err}
+		}
+
+		// SIGTERM drains the in-flight requests before exit
+		ctx, cancel := suggester.ShutdownContext()
+		defer cancel()
+
+		return server.ListenAndServe(ctx)
+	}
+}
+
+func fetch(flags *flag.FlagSet) func(env *env, args []string) error {
+	dedup := flags.Bool("dedup", false, "Skip items already fetched for the category.")
+	format := flags.String("format", "", "Data set format: json or jsonl.gz, the configured one by default.")
+	showProgress := progressFlag(flags)
 
-priceSuggester is a tool for suggest prices given a category Id.
+	return func(env *env, args []string) error {
+		s, err := env.loadSuggester()
+		if err != nil {
+			return err
+		}
 
-Usage: priceSuggester <command>
+		if *showProgress {
+			bar := newProgressBar(os.Stderr)
+			defer bar.finish()
+			s.AddObserver(bar)
+		}
 
-Commands:
+		s.SetDeduplicateOnFetch(*dedup)
 
-  fetch            Fetch data set of items by categories.
-  train	           Train the data set.
-  suggest          Suggest a price given a category.
-  clean            Clean data set and data trained folders.
-  serve            Serve a http service 8080 port.
-  help             Help Meli Price Suggester.
+		if *format != "" {
+			s.SetDataSetFormat(*format)
+		}
 
-Examples:
-  priceSuggester fetch
-  priceSuggester fetch MLA1743
-  priceSuggester train
-  priceSuggester serve
-  priceSuggester suggest MLA70400
+		if len(args) == 1 {
+			return s.FetchItemsBySystematicRandomSampling(s.Config().Site, args[0])
+		}
 
-	`)
+		return s.FetchDataSet(s.Config().Site)
+	}
 }
 
-func serve() {
+func dataSetConvert(flags *flag.FlagSet) func(env *env, args []string) error {
+	format := flags.String("format", suggester.DATA_SET_FORMAT_JSONL_GZIP, "Data set format to convert to: json or jsonl.gz.")
 
-	s := suggester.NewSuggesterCtrl()
+	return func(env *env, args []string) error {
+		s, err := env.loadSuggester()
+		if err != nil {
+			return err
+		}
 
-	r := gin.Default()
+		categories, err := dataSetArgCategories(s, args)
+		if err != nil {
+			return err
+		}
 
-	r.GET("/categories/:categoryId/prices", s.SuggestPriceByCategory)
+		var categoryErrors suggester.CategoryErrors
 
-	r.Run(":8080")
+		for _, categoryId := range categories {
+			err := s.ConvertDataSet(categoryId, *format)
+			if err != nil {
+				categoryErrors = append(categoryErrors, &suggester.CategoryError{Op: suggester.DATA_SET_CONVERT, CategoryId: categoryId, Err: err})
+			}
+		}
+
+		if len(categoryErrors) > 0 {
+			return categoryErrors
+		}
+
+		return nil
+	}
 }
 
-func main() {
+func dataSetQuery(flags *flag.FlagSet) func(env *env, args []string) error {
+	categoryId := flags.String("category", "", "Category of the items.")
+	snapshot := flags.String("snapshot", "", "Snapshot date YYYY-MM-DD, the latest one by default.")
+	minPrice := flags.Float64("min-price", 0, "Minimum item price.")
+	maxPrice := flags.Float64("max-price", 0, "Maximum item price, without bound by default.")
+	format := outputFlag(flags, OUTPUT_JSON)
+
+	return func(env *env, args []string) error {
+		if *categoryId == "" {
+			return errMissingArgs
+		}
+
+		query := suggester.ItemQuery{CategoryId: *categoryId, MinPrice: *minPrice, MaxPrice: *maxPrice}
+
+		if *snapshot != "" {
+			date, err := time.Parse(suggester.SNAPSHOT_DATE_LAYOUT, *snapshot)
+			if err != nil {
+				return &exitError{code: EXIT_USAGE, err: err}
+			}
+			query.Snapshot = date
+		}
+
+		s, err := env.loadSuggester()
+		if err != nil {
+			return err
+		}
+
+		items, err := s.QueryItems(query)
+		if err != nil {
+			return err
+		}
+
+		out := output{value: items, header: []string{"id", "title", "price", "currency_id", "category_id", "seller_id"}}
+		for _, item := range items {
+			out.rows = append(out.rows, []interface{}{item.Id, item.Title, item.Price, item.Currency, item.CategoryId, item.Seller.Id})
+		}
+
+		return out.print(os.Stdout, *format)
+	}
+}
+
+func dataSetStats(flags *flag.FlagSet) func(env *env, args []string) error {
+	format := outputFlag(flags, OUTPUT_TABLE)
+
+	return func(env *env, args []string) error {
+		s, err := env.loadSuggester()
+		if err != nil {
+			return err
+		}
+
+		categories, err := dataSetArgCategories(s, args)
+		if err != nil {
+			return &exitError{code: EXIT_USAGE, err: err}
+		}
+
+		allStats := []suggester.DataSetStats{}
+		var categoryErrors suggester.CategoryErrors
+
+		for _, categoryId := range categories {
+			stats, err := s.DataSetStats(categoryId)
+			if err != nil {
+				categoryErrors = append(categoryErrors, &suggester.CategoryError{Op: suggester.DATA_SET_STATS, CategoryId: categoryId, Err: err})
+				continue
+			}
+			allStats = append(allStats, stats)
+		}
+
+		if err := dataSetStatsOutput(allStats).print(os.Stdout, *format); err != nil {
+			return err
+		}
+
+		if len(categoryErrors) > 0 {
+			return categoryErrors
+		}
+
+		return nil
+	}
+}
+
+func dataSetValidate(flags *flag.FlagSet) func(env *env, args []string) error {
+	format := outputFlag(flags, OUTPUT_TABLE)
+
+	return func(env *env, args []string) error {
+		s, err := env.loadSuggester()
+		if err != nil {
+			return err
+		}
+
+		categories, err := dataSetArgCategories(s, args)
+		if err != nil {
+			return &exitError{code: EXIT_USAGE, err: err}
+		}
+
+		issues := []suggester.DataSetIssue{}
+
+		for _, categoryId := range categories {
+			categoryIssues, err := s.ValidateDataSet(categoryId)
+			if err != nil {
+				return &exitError{code: EXIT_USAGE, err: errors.New(fmt.Sprintf("Error validating category: %s %s", categoryId, err))}
+			}
+			issues = append(issues, categoryIssues...)
+		}
+
+		out := output{
+			value:   issues,
+			header:  []string{"category_id", "snapshot_date", "source", "kind", "message"},
+			summary: []string{fmt.Sprintf("%d issues in %d categories", len(issues), len(categories))},
+		}
+		for _, issue := range issues {
+			out.rows = append(out.rows, []interface{}{issue.CategoryId, issue.SnapshotDate, issue.Source, issue.Kind, issue.Message})
+		}
+
+		if err := out.print(os.Stdout, *format); err != nil {
+			return err
+		}
+
+		if len(issues) > 0 {
+			return errors.New(fmt.Sprintf("%d data set issues found", len(issues)))
+		}
+
+		return nil
+	}
+}
+
+// dataSetArgCategories returns the categories given as arguments, every category of the data set by default.
+func dataSetArgCategories(s *suggester.Suggester, args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+
+	return s.DataSetCategories()
+}
+
+func dataSetStatsOutput(allStats []suggester.DataSetStats) output {
+	out := output{
+		value: allStats,
+		header: []string{"category_id", "snapshot_date", "snapshots", "files", "items", "currencies",
+			"min", "p25", "median", "p75", "max", "sample_size", "total_items", "coverage"},
+	}
+
+	for _, stats := range allStats {
+		var sample, total interface{}
+		if stats.Fetch != nil {
+			sample = stats.Fetch.SampleSize
+			total = stats.Fetch.TotalItems
+		}
+
+		out.rows = append(out.rows, []interface{}{
+			stats.CategoryId, stats.SnapshotDate, stats.Snapshots, stats.Files, stats.Items, counts(stats.Currencies),
+			stats.Price.Min, stats.Price.P25, stats.Price.Median, stats.Price.P75, stats.Price.Max,
+			sample, total, percent(stats.Coverage),
+		})
+	}
+
+	return out
+}
+
+func train(flags *flag.FlagSet) func(env *env, args []string) error {
+	categories := flags.String("category", "", "Comma separated categories to train, keeping the rest of the model.")
+	incremental := flags.Bool("incremental", false, "Train only the categories whose data set changed since the last training.")
+	dropNearDuplicates := flags.Bool("drop-near-duplicates", false, "Skip listings with the same seller and title of an item already trained.")
+	format := outputFlag(flags, OUTPUT_TABLE)
+	maxUnreadable := flags.Int("max-unreadable-files", -1, "Exit with code 1 when more files than this can not be read. Negative disables it.")
+	maxFailed := flags.Int("max-failed-categories", -1, "Exit with code 1 when more categories than this can not be read. Negative disables it.")
+	maxDropped := flags.Float64("max-dropped-ratio", -1, "Exit with code 1 when a larger share of the items read is dropped. Negative disables it.")
+	showProgress := progressFlag(flags)
+
+	return func(env *env, args []string) error {
+		s, err := env.loadSuggester()
+		if err != nil {
+			return err
+		}
+
+		bar := newProgressBar(os.Stderr)
+		if *showProgress {
+			s.AddObserver(bar)
+		}
+
+		options := suggester.TrainOptions{
+			Incremental:        *incremental,
+			DropNearDuplicates: *dropNearDuplicates,
+			Thresholds: &suggester.TrainThresholds{
+				MaxUnreadableFiles: *maxUnreadable,
+				MaxFailedFolders:   *maxFailed,
+				MaxDroppedRatio:    *maxDropped,
+			},
+		}
+
+		if *categories != "" {
+			options.Categories = strings.Split(*categories, ",")
+		}
+
+		report, err := s.TrainWithOptions(options)
+		bar.finish()
+
+		// The categories that can not be read were checked against the thresholds
+		_, partial := err.(suggester.CategoryErrors)
+		_, exceeded := err.(suggester.ThresholdsError)
+
+		if err != nil && !partial && !exceeded {
+			return err
+		}
+
+		if err := trainReportOutput(report).print(os.Stdout, *format); err != nil {
+			return err
+		}
+
+		if partial {
+			fmt.Fprintln(os.Stderr, err)
+			return nil
+		}
+
+		return err
+	}
+}
+
+func trainReportOutput(report suggester.TrainReport) output {
+	categories := make([]string, 0, len(report.Categories))
+	for categoryId := range report.Categories {
+		categories = append(categories, categoryId)
+	}
+	sort.Strings(categories)
+
+	out := output{
+		value: report,
+		header: []string{"category_id", "snapshot_date", "items", "trained", "duplicates", "near_duplicates",
+			"unreadable_files", "suggested", "min", "max", "error"},
+		summary: []string{fmt.Sprintf("Categories trained: %d  Model categories: %d  Items: %d  Trained: %d  Unreadable files: %d  Duration: %.1fs",
+			len(report.Categories), report.ModelCategories, report.Items, report.Trained, report.UnreadableFiles, report.Duration)},
+	}
+
+	for _, categoryId := range categories {
+		category := report.Categories[categoryId]
+
+		var suggested, min, max interface{}
+		if category.Stats != nil {
+			suggested = category.Stats.Suggested
+			min = category.Stats.Min
+			max = category.Stats.Max
+		}
+
+		out.rows = append(out.rows, []interface{}{
+			categoryId, category.SnapshotDate, category.Items, category.Trained,
+			category.Dropped[suggester.DROPPED_DUPLICATE], category.Dropped[suggester.DROPPED_NEAR_DUPLICATE],
+			len(category.UnreadableFiles), suggested, min, max, category.Error,
+		})
+	}
+
+	return out
+}
+
+// categorySuggestion is the CategoryPriceSuggested of the HTTP API with the
+// category it was suggested for, or why it could not be.
+type categorySuggestion struct {
+	CategoryId string `json:"category_id"`
+	suggester.CategoryPriceSuggested
+	Error string `json:"error,omitempty"`
+}
+
+func suggest(flags *flag.FlagSet) func(env *env, args []string) error {
+	adjust := flags.String("adjust", suggester.ADJUSTMENT_NONE, "Adjust prices to today with: trend or index.")
+	format := outputFlag(flags, OUTPUT_TEXT)
+
+	return func(env *env, args []string) error {
+		if len(args) == 0 {
+			return errMissingArgs
+		}
 
-	args := os.Args[1:]
+		s, err := env.loadModel()
+		if err != nil {
+			return err
+		}
+
+		if err := validateCategoryArgs(s, args); err != nil {
+			return err
+		}
+
+		var suggestions []categorySuggestion
+		var categoryErrors suggester.CategoryErrors
+
+		for _, categoryId := range args {
+			priceSuggested, err := s.SuggestAdjusted(categoryId, *adjust)
+
+			suggestion := categorySuggestion{CategoryId: categoryId, CategoryPriceSuggested: priceSuggested}
+			if err != nil {
+				suggestion.Error = err.Error()
+				categoryErrors = append(categoryErrors, &suggester.CategoryError{Op: suggester.SUGGEST, CategoryId: categoryId, Err: err})
+			}
+
+			suggestions = append(suggestions, suggestion)
+		}
+
+		if err := suggestOutput(suggestions).print(os.Stdout, *format); err != nil {
+			return err
+		}
+
+		if len(categoryErrors) > 0 {
+			return categoryErrors
+		}
+
+		return nil
+	}
+}
+
+// validateCategoryArgs checks the categories given are category ids of the
+// configured site, as the API does, so a typo is a usage error.
+func validateCategoryArgs(s *suggester.Suggester, categories []string) error {
+	for _, categoryId := range categories {
+		if err := s.ValidateCategoryId(categoryId); err != nil {
+			return &exitError{code: EXIT_USAGE, err: err}
+		}
+	}
+
+	return nil
+}
+
+func suggestOutput(suggestions []categorySuggestion) output {
+	out := output{
+		value: suggestions,
+		header: []string{"category_id", "suggested", "min", "max",
+			"adjustment_method", "adjustment_factor", "adjustment_snapshot_date", "raw_suggested", "error"},
+		text: func(w io.Writer) {
+			for _, suggestion := range suggestions {
+				if suggestion.Error != "" {
+					continue
+				}
+
+				fmt.Fprintf(w, "For category: %s  Price suggested: %f , Min: %f, Max: %f",
+					suggestion.CategoryId,
+					suggestion.Suggested,
+					suggestion.Min,
+					suggestion.Max)
+
+				if adjustment := suggestion.Adjustment; adjustment != nil {
+					fmt.Fprintf(w, " (Raw suggested: %f, Adjustment factor: %f by %s since %s)",
+						adjustment.Raw.Suggested,
+						adjustment.Factor,
+						adjustment.Method,
+						adjustment.SnapshotDate.Format(suggester.SNAPSHOT_DATE_LAYOUT))
+				}
+				fmt.Fprintln(w)
+			}
+		},
+	}
+
+	for _, suggestion := range suggestions {
+		row := []interface{}{suggestion.CategoryId, nil, nil, nil, nil, nil, nil, nil, suggestion.Error}
+
+		if suggestion.Error == "" {
+			row[1], row[2], row[3] = suggestion.Suggested, suggestion.Min, suggestion.Max
+		}
+
+		if adjustment := suggestion.Adjustment; adjustment != nil {
+			row[4], row[5], row[6], row[7] = adjustment.Method, adjustment.Factor, adjustment.SnapshotDate, adjustment.Raw.Suggested
+		}
 
-	if len(args) == 0 {
-		printHelp()
-		return
+		out.rows = append(out.rows, row)
 	}
 
-	s := suggester.NewSuggester()
+	return out
+}
+
+func trend(flags *flag.FlagSet) func(env *env, args []string) error {
+	format := outputFlag(flags, OUTPUT_TEXT)
+
+	return func(env *env, args []string) error {
+		if len(args) == 0 {
+			return errMissingArgs
+		}
+
+		s, err := env.loadModel()
+		if err != nil {
+			return err
+		}
 
-	switch args[0] {
-	case suggester.FETCH_DATA_SET:
-		if len(args) == 2 {
-			s.FetchItemsBySystematicRandomSampling(meli.SITE_MLA, args[1])
-		} else {
-			s.FetchDataSet(meli.SITE_MLA)
+		if err := validateCategoryArgs(s, args); err != nil {
+			return err
 		}
-	case suggester.TRAIN_MODEL:
-		s.Train()
-	case suggester.SUGGEST:
-		if len(args) >= 2 {
-			categoryId := args[1]
-			priceSuggested, err := s.Suggest(categoryId)
+
+		trends := []suggester.CategoryPriceTrend{}
+		var categoryErrors suggester.CategoryErrors
+
+		for _, categoryId := range args {
+			trend, err := s.Trend(categoryId)
 			if err != nil {
-				fmt.Println(err)
-				break
+				categoryErrors = append(categoryErrors, &suggester.CategoryError{Op: suggester.TREND, CategoryId: categoryId, Err: err})
+				continue
+			}
+			trends = append(trends, trend)
+		}
+
+		if err := trendOutput(trends).print(os.Stdout, *format); err != nil {
+			return err
+		}
+
+		if len(categoryErrors) > 0 {
+			return categoryErrors
+		}
+
+		return nil
+	}
+}
+
+func trendOutput(trends []suggester.CategoryPriceTrend) output {
+	out := output{
+		value:  trends,
+		header: []string{"category_id", "monthly_rate", "date", "median", "total"},
+		text: func(w io.Writer) {
+			for _, trend := range trends {
+				fmt.Fprintf(w, "For category: %s\n", trend.CategoryId)
+				for _, point := range trend.Series {
+					fmt.Fprintf(w, "%s  Median: %f  Items: %d\n", point.Date.Format(suggester.SNAPSHOT_DATE_LAYOUT), point.Median, point.Total)
+				}
+				fmt.Fprintf(w, "Monthly rate of change: %.2f%%\n", trend.MonthlyRate*100)
+			}
+		},
+	}
+
+	for _, trend := range trends {
+		for _, point := range trend.Series {
+			out.rows = append(out.rows, []interface{}{trend.CategoryId, percent(trend.MonthlyRate), point.Date, point.Median, point.Total})
+		}
+	}
+
+	return out
+}
+
+func clean(flags *flag.FlagSet) func(env *env, args []string) error {
+	return func(env *env, args []string) error {
+		s, err := env.loadSuggester()
+		if err != nil {
+			return err
+		}
+
+		return s.Clean()
+	}
+}
+
+func evaluate(flags *flag.FlagSet) func(env *env, args []string) error {
+	holdoutRatio := flags.Float64("holdout", suggester.DEFAULT_HOLDOUT_RATIO, "Ratio of items per category held out for scoring.")
+	seed := flags.Int64("seed", 1, "Seed used to split the data set.")
+	format := outputFlag(flags, OUTPUT_JSON)
+
+	return func(env *env, args []string) error {
+		s, err := env.loadSuggester()
+		if err != nil {
+			return err
+		}
+
+		report, err := s.Evaluate(*holdoutRatio, *seed)
+
+		// The categories that can not be read are returned after the report of the rest
+		if _, partial := err.(suggester.CategoryErrors); err != nil && !partial {
+			return err
+		}
+
+		if err := evaluationOutput(report).print(os.Stdout, *format); err != nil {
+			return err
+		}
+
+		return err
+	}
+}
+
+func evaluationOutput(report suggester.EvaluationReport) output {
+	categories := make([]string, 0, len(report.Categories))
+	for categoryId := range report.Categories {
+		categories = append(categories, categoryId)
+	}
+	sort.Strings(categories)
+
+	overall := report.Overall
+	out := output{
+		value:  report,
+		header: []string{"category_id", "train_size", "holdout_size", "mae", "mape", "median_ape", "coverage"},
+		summary: []string{fmt.Sprintf("Overall  Train: %d  Holdout: %d  MAE: %.2f  MAPE: %.2f%%  Median APE: %.2f%%  Coverage: %.2f%%  Unreadable files: %d",
+			overall.TrainSize, overall.HoldoutSize, overall.MAE, overall.MAPE*100, overall.MedianAPE*100, overall.Coverage*100, report.UnreadableFiles)},
+	}
+
+	for _, categoryId := range categories {
+		metrics := report.Categories[categoryId]
+		out.rows = append(out.rows, []interface{}{categoryId, metrics.TrainSize, metrics.HoldoutSize,
+			metrics.MAE, percent(metrics.MAPE), percent(metrics.MedianAPE), percent(metrics.Coverage)})
+	}
+
+	return out
+}
+
+// diff compares model files only, it does not need the configuration.
+func diff(flags *flag.FlagSet) func(env *env, args []string) error {
+	format := outputFlag(flags, OUTPUT_TABLE)
+	top := flags.Int("top", 10, "Number of largest movers to show in table and text output, csv has every change.")
+	maxChange := flags.Float64("max-change", -1, "Exit with code 1 when a category moves more than this relative change. Negative disables it.")
+	maxRemoved := flags.Int("max-removed", -1, "Exit with code 1 when more categories than this are removed. Negative disables it.")
+
+	return func(env *env, args []string) error {
+		if len(args) != 2 {
+			return errMissingArgs
+		}
+
+		oldModel, err := suggester.ReadModelFile(args[0])
+		if err != nil {
+			return &exitError{code: EXIT_USAGE, err: err}
+		}
+
+		newModel, err := suggester.ReadModelFile(args[1])
+		if err != nil {
+			return &exitError{code: EXIT_USAGE, err: err}
+		}
+
+		modelDiff := suggester.DiffModels(oldModel, newModel)
+
+		movers := modelDiff.LargestMovers(*top)
+		if *format == OUTPUT_CSV {
+			movers = modelDiff.Changes
+		}
+
+		if err := diffOutput(modelDiff, movers).print(os.Stdout, *format); err != nil {
+			return err
+		}
+
+		var exceeded []string
+
+		if *maxChange >= 0 {
+			if exceeding := modelDiff.ChangesExceeding(*maxChange); len(exceeding) > 0 {
+				exceeded = append(exceeded, fmt.Sprintf("%d categories moved more than %.2f%%", len(exceeding), *maxChange*100))
 			}
-			fmt.Printf("For category: %s  Price suggested: %f , Min: %f, Max: %f",
-				categoryId,
-				priceSuggested.Suggested,
-				priceSuggested.Min,
-				priceSuggested.Max)
-		} else {
-			printHelp()
 		}
 
-	case suggester.SERVE:
-		serve()
-	case suggester.CLEAN:
-		s.Clean()
-	default:
-		printHelp()
+		if *maxRemoved >= 0 && len(modelDiff.Removed) > *maxRemoved {
+			exceeded = append(exceeded, fmt.Sprintf("%d categories removed, limit is %d", len(modelDiff.Removed), *maxRemoved))
+		}
+
+		if len(exceeded) > 0 {
+			return errors.New(strings.Join(exceeded, "\n"))
+		}
+
+		return nil
+	}
+}
+
+func diffOutput(modelDiff suggester.ModelDiff, movers []suggester.CategoryPriceChange) output {
+	out := output{
+		value:  modelDiff,
+		header: []string{"category_id", "suggested", "suggested_change", "min", "min_change", "max", "max_change"},
+		summary: []string{
+			fmt.Sprintf("Added categories: %d %v", len(modelDiff.Added), modelDiff.Added),
+			fmt.Sprintf("Removed categories: %d %v", len(modelDiff.Removed), modelDiff.Removed),
+			fmt.Sprintf("Changed categories: %d", len(modelDiff.Changes)),
+		},
+	}
+
+	for _, change := range movers {
+		out.rows = append(out.rows, []interface{}{change.CategoryId,
+			change.Suggested.New, percent(change.Suggested.Relative),
+			change.Min.New, percent(change.Min.Relative),
+			change.Max.New, percent(change.Max.Relative)})
 	}
 
-	var input string
-	fmt.Scanln(&input)
+	return out
+}
+
+func configShow(flags *flag.FlagSet) func(env *env, args []string) error {
+	return func(env *env, args []string) error {
+		config, err := env.loadConfig()
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(config)
+
+		return nil
+	}
+}
+
+// newGlobalFlags returns the config flags given before the command.
+func newGlobalFlags() (*flag.FlagSet, *string) {
+	globalFlags := flag.NewFlagSet(PROGRAM, flag.ExitOnError)
+	configFile := globalFlags.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file (env CONFIG_FILE).")
+	suggester.RegisterFlags(globalFlags)
+
+	return globalFlags, configFile
+}
+
+func main() {
+
+	root := commandTree()
+
+	globalFlags, configFile := newGlobalFlags()
+	globalFlags.Usage = func() {
+		root.printHelp(os.Stderr, nil, nil)
+	}
+	globalFlags.Parse(os.Args[1:])
+
+	env := &env{configFile: *configFile, globalFlags: globalFlags}
+	err := root.run(env, globalFlags.Args())
+	env.close()
+
+	// Failed commands exit with 1, invalid usage or configuration with 2
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		if exitErr, ok := err.(*exitError); ok {
+			os.Exit(exitErr.code)
+		}
+		os.Exit(EXIT_FAILURE)
+	}
 }
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/jesusfar/meli.price.suggester/suggester"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+const checkMark = "✓"
+
+func TestCommand_Resolve(t *testing.T) {
+	root := commandTree()
+
+	cmd, path, args := root.resolve([]string{"dataset", "stats", "-output", "json", "MLA1743"})
+
+	t.Log("Given a nested command, it resolves the command and keeps its arguments.", checkMark)
+	assert.Equal(t, "stats", cmd.name)
+	assert.Equal(t, []string{"dataset", "stats"}, path)
+	assert.Equal(t, []string{"-output", "json", "MLA1743"}, args)
+
+	cmd, path, args = root.resolve([]string{"unknown"})
+
+	t.Log("Given an unknown command, it stops at the root.", checkMark)
+	assert.Equal(t, root, cmd)
+	assert.Empty(t, path)
+	assert.Equal(t, []string{"unknown"}, args)
+}
+
+func TestRootHelp(t *testing.T) {
+	flags := flag.NewFlagSet(PROGRAM, flag.ContinueOnError)
+	suggester.RegisterFlags(flags)
+
+	t.Log("Given the config flags, the root help lists each of them.", checkMark)
+	flags.VisitAll(func(f *flag.Flag) {
+		assert.Regexp(t, "-"+regexp.QuoteMeta(f.Name)+"[,\n]", rootHelp)
+	})
+}
+
+func TestCompleteWords(t *testing.T) {
+	root := commandTree()
+	globalFlags, _ := newGlobalFlags()
+
+	t.Log("Given a prefix, it completes the commands.", checkMark)
+	assert.Equal(t, []string{"serve", "suggest"}, completeWords(root, globalFlags, []string{"s"}))
+
+	t.Log("Given a command group, it completes its subcommands.", checkMark)
+	assert.Equal(t, []string{"convert", "query", "stats", "validate"}, completeWords(root, globalFlags, []string{"dataset", ""}))
+
+	t.Log("Given config flags with values before the command, it completes the command flags.", checkMark)
+	assert.Equal(t, []string{"--max-dropped-ratio", "--max-failed-categories", "--max-unreadable-files"},
+		completeWords(root, globalFlags, []string{"-data-set-path", "train", "train", "--max"}))
+
+	t.Log("Given no command, it completes the config flags.", checkMark)
+	assert.Equal(t, []string{"-storage"}, completeWords(root, globalFlags, []string{"-st"}))
+}
+
+func TestSuggestOutput(t *testing.T) {
+	suggestions := []categorySuggestion{
+		{CategoryId: "MLA1743", CategoryPriceSuggested: suggester.CategoryPriceSuggested{Suggested: 150.5, Min: 100, Max: 200}},
+		{CategoryId: "MLA0", Error: "Category: MLA0 not found"},
+	}
+	out := suggestOutput(suggestions)
+
+	t.Log("Given json output, it prints the CategoryPriceSuggested fields of every category.", checkMark)
+	{
+		var buffer bytes.Buffer
+		assert.Nil(t, out.print(&buffer, OUTPUT_JSON))
+
+		var printed []map[string]interface{}
+		assert.Nil(t, json.Unmarshal(buffer.Bytes(), &printed))
+		assert.Len(t, printed, 2)
+		assert.Equal(t, "MLA1743", printed[0]["category_id"])
+		assert.Equal(t, 150.5, printed[0]["suggested"])
+		assert.Equal(t, 100.0, printed[0]["min"])
+		assert.Equal(t, 200.0, printed[0]["max"])
+		assert.Equal(t, "Category: MLA0 not found", printed[1]["error"])
+	}
+
+	t.Log("Given csv output, it prints a header and a row per category.", checkMark)
+	{
+		var buffer bytes.Buffer
+		assert.Nil(t, out.print(&buffer, OUTPUT_CSV))
+		assert.Equal(t, "category_id,suggested,min,max,adjustment_method,adjustment_factor,adjustment_snapshot_date,raw_suggested,error\n"+
+			"MLA1743,150.5,100,200,,,,,\n"+
+			"MLA0,,,,,,,,Category: MLA0 not found\n", buffer.String())
+	}
+
+	t.Log("Given text output, it prints a line per suggested category.", checkMark)
+	{
+		var buffer bytes.Buffer
+		assert.Nil(t, out.print(&buffer, OUTPUT_TEXT))
+		assert.Equal(t, "For category: MLA1743  Price suggested: 150.500000 , Min: 100.000000, Max: 200.000000\n", buffer.String())
+	}
+}
+
+func TestOutputFormat_Set(t *testing.T) {
+	var format outputFormat
+
+	t.Log("Given a known format, it is set.", checkMark)
+	assert.Nil(t, format.Set(OUTPUT_CSV))
+	assert.Equal(t, outputFormat(OUTPUT_CSV), format)
+
+	t.Log("Given an unknown format, it fails.", checkMark)
+	assert.NotNil(t, format.Set("xml"))
+}
+
+func TestValidateCategoryArgs(t *testing.T) {
+	s := suggester.NewSuggester(suggester.DefaultConfig())
+
+	t.Log("Given categories of the site, they are valid.", checkMark)
+	assert.Nil(t, validateCategoryArgs(s, []string{"MLA1051", "MLA1743"}))
+
+	t.Log("Given a malformed category, it is a usage error.", checkMark)
+	err := validateCategoryArgs(s, []string{"MLA1051", "MLA-1743"})
+	if assert.IsType(t, &exitError{}, err) {
+		assert.Equal(t, EXIT_USAGE, err.(*exitError).code)
+	}
+}
+
+func TestDataSetCommands_CategoryErrors(t *testing.T) {
+	folder, _ := ioutil.TempDir("", "suggester")
+	defer os.RemoveAll(folder)
+
+	config := suggester.DefaultConfig()
+	config.DataSetPath = filepath.Join(folder, "dataset")
+	config.DataTrainedPath = filepath.Join(folder, "datatrained")
+
+	e := &env{suggester: suggester.NewSuggester(config)}
+
+	t.Log("Given a category without data set, convert and stats return it as a CategoryError.", checkMark)
+	for _, run := range []func(flags *flag.FlagSet) func(env *env, args []string) error{dataSetConvert, dataSetStats} {
+		err := run(flag.NewFlagSet("dataset", flag.ContinueOnError))(e, []string{"MLA1051"})
+		if assert.IsType(t, suggester.CategoryErrors{}, err) {
+			assert.Equal(t, []string{"MLA1051"}, err.(suggester.CategoryErrors).Categories())
+		}
+	}
+}
+
+func TestEnv_LoadModel(t *testing.T) {
+	folder, _ := ioutil.TempDir("", "suggester")
+	defer os.RemoveAll(folder)
+
+	config := suggester.DefaultConfig()
+	config.DataSetPath = filepath.Join(folder, "dataset")
+	config.DataTrainedPath = filepath.Join(folder, "datatrained")
+
+	_, err := (&env{suggester: suggester.NewSuggester(config)}).loadModel()
+
+	t.Log("Given no model trained, loadModel returns the suggester without model.", checkMark)
+	assert.Nil(t, err)
+
+	os.MkdirAll(config.DataTrainedPath, 0777)
+	ioutil.WriteFile(filepath.Join(config.DataTrainedPath, suggester.DATA_TRAINED_FILE), []byte("{"), 0644)
+
+	_, err = (&env{suggester: suggester.NewSuggester(config)}).loadModel()
+
+	t.Log("Given a model that can not be decoded, loadModel returns error with its path.", checkMark)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), config.DataTrainedPath)
+	}
+}
+
+func TestProgressLine(t *testing.T) {
+	t.Log("Given a page fetched, the line has the bar, the categories done, the page and the ETA.", checkMark)
+	line := progressLine(suggester.ProgressEvent{
+		Type: suggester.EVENT_PAGE_FETCHED, Op: suggester.FETCH_DATA_SET, CategoryId: "MLA1743",
+		Done: 1, Total: 3, Pages: 2, Items: 100, EtaSeconds: 80,
+	})
+	assert.Equal(t, "fetch [==========                    ] 1/3 categories MLA1743 page 2, 100 items ETA 1m20s", line)
+}
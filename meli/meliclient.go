@@ -18,9 +18,22 @@ type Category struct {
 }
 
 type SearchItemsResult struct {
-	SiteId  string       `json:"site_id"`
-	Paging  PageInfo     `json:"paging"`
-	Results []SearchItem `json:"results"`
+	SiteId  string         `json:"site_id"`
+	Paging  PageInfo       `json:"paging"`
+	Results []SearchItem   `json:"results"`
+	Filters []SearchFilter `json:"filters"`
+}
+
+// SearchFilter is a filter applied to a search, as the category searched.
+type SearchFilter struct {
+	Id     string              `json:"id"`
+	Values []SearchFilterValue `json:"values"`
+}
+
+type SearchFilterValue struct {
+	Id           string     `json:"id"`
+	Name         string     `json:"name"`
+	PathFromRoot []Category `json:"path_from_root"`
 }
 
 type PageInfo struct {
@@ -31,9 +44,50 @@ type PageInfo struct {
 }
 
 type SearchItem struct {
-	Id         string  `json:"id"`
-	Title      string  `json:"title"`
-	Price      float64 `json:"price"`
-	Currency   string  `json:"currency_id"`
-	CategoryId string  `json:"category_id"`
+	Id         string       `json:"id"`
+	Title      string       `json:"title"`
+	Price      float64      `json:"price"`
+	Currency   string       `json:"currency_id"`
+	CategoryId string       `json:"category_id"`
+	Seller     SearchSeller `json:"seller"`
+}
+
+type SearchSeller struct {
+	Id int `json:"id"`
+}
+
+// CategoryPath returns the ids of the categories from the root to the
+// category searched, or nil when the search was not filtered by category.
+func (r *SearchItemsResult) CategoryPath() []string {
+	for _, filter := range r.Filters {
+		if filter.Id != "category" || len(filter.Values) == 0 {
+			continue
+		}
+
+		var path []string
+		for _, category := range filter.Values[0].PathFromRoot {
+			path = append(path, category.Id)
+		}
+		return path
+	}
+
+	return nil
+}
+
+// CategoryNames returns the names of the categories from the root to the
+// category searched by id, or nil when the search was not filtered by category.
+func (r *SearchItemsResult) CategoryNames() map[string]string {
+	for _, filter := range r.Filters {
+		if filter.Id != "category" || len(filter.Values) == 0 {
+			continue
+		}
+
+		names := make(map[string]string)
+		for _, category := range filter.Values[0].PathFromRoot {
+			names[category.Id] = category.Name
+		}
+		return names
+	}
+
+	return nil
 }
@@ -13,10 +13,13 @@ import (
 
 const MELI_API_ENDPOINT = "https://api.mercadolibre.com"
 const MAX_RETRIES = 20
+const RETRY_DELAY = time.Millisecond * 1000
 
 type MeliHttpClient struct {
-	endpoint string
-	logger   *util.Logger
+	endpoint   string
+	maxRetries int
+	retryDelay time.Duration
+	logger     *util.Logger
 }
 
 func NewMeliHttpClient() *MeliHttpClient {
@@ -28,8 +31,10 @@ func NewMeliHttpClient() *MeliHttpClient {
 	}
 
 	client := MeliHttpClient{
-		endpoint: endpoint,
-		logger:   util.NewLogger(),
+		endpoint:   endpoint,
+		maxRetries: MAX_RETRIES,
+		retryDelay: RETRY_DELAY,
+		logger:     util.NewLogger(),
 	}
 
 	return &client
@@ -43,6 +48,12 @@ func (m *MeliHttpClient) GetEndpoint() string {
 	return m.endpoint
 }
 
+// SetRetryPolicy sets how many times and how often a failed search is retried.
+func (m *MeliHttpClient) SetRetryPolicy(maxRetries int, retryDelay time.Duration) {
+	m.maxRetries = maxRetries
+	m.retryDelay = retryDelay
+}
+
 func (m *MeliHttpClient) GetCategories(site string) ([]Category, error) {
 
 	var categories []Category
@@ -77,7 +88,7 @@ func (m *MeliHttpClient) SearchItems(site string, query string, offset int, limi
 	var res *rest.Response
 	var err error
 
-	for i:= MAX_RETRIES; i >= 0; i--  {
+	for i := m.maxRetries; i >= 0; i-- {
 
 		url = fmt.Sprintf("%s/sites/%s/search?%s&offset=%v&limit=%v", m.endpoint, site, query, offset, limit)
 		m.logger.Debug(url)
@@ -86,7 +97,7 @@ func (m *MeliHttpClient) SearchItems(site string, query string, offset int, limi
 
 		if !m.isSuccess(res) {
 			m.logger.Debug(fmt.Sprintf("[SearchItems] Retrying to search items... left retries: [%d]", i))
-			time.Sleep(time.Millisecond * 1000)
+			time.Sleep(m.retryDelay)
 			continue
 		}
 
@@ -105,7 +116,7 @@ func (m *MeliHttpClient) SearchItems(site string, query string, offset int, limi
 	}
 
 	// Return error
-	err = MeliClientErr{Message: "Error searching items after 10 tries.."}
+	err = MeliClientErr{Message: fmt.Sprintf("Error searching items after %d tries..", m.maxRetries+1)}
 	return nil, err
 }
 
@@ -90,6 +90,10 @@ func TestMeliHttpClient_SearchItems(t *testing.T) {
 		t.Log("SearchItems return SearchItemResult", checkMark)
 		assert.NotNil(t, result)
 		assert.IsType(t, &SearchItemsResult{}, result)
+
+		t.Log("SearchItemResult has the path of the category searched", checkMark)
+		assert.Equal(t, []string{"MLA1051"}, result.CategoryPath())
+		assert.Equal(t, map[string]string{"MLA1051": "Celulares y Teléfonos"}, result.CategoryNames())
 	}
 
 }
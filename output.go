@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/suggester"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	OUTPUT_TEXT  = "text"
+	OUTPUT_TABLE = "table"
+	OUTPUT_JSON  = "json"
+	OUTPUT_CSV   = "csv"
+)
+
+// outputFormat is the value of the -output flag of the reporting commands.
+type outputFormat string
+
+func (o *outputFormat) String() string {
+	return string(*o)
+}
+
+func (o *outputFormat) Set(value string) error {
+	switch value {
+	case OUTPUT_TEXT, OUTPUT_TABLE, OUTPUT_JSON, OUTPUT_CSV:
+		*o = outputFormat(value)
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("Output format: %s is not text, table, json or csv.", value))
+}
+
+func outputFlag(flags *flag.FlagSet, defaultFormat string) *outputFormat {
+	format := outputFormat(defaultFormat)
+	flags.Var(&format, "output", "Output format: text, table, json or csv.")
+
+	return &format
+}
+
+// percent is a ratio printed as a percentage in tables.
+type percent float64
+
+// output is the result of a reporting command. The value is printed as json,
+// the rows as a table or csv, and text is the human readable output, the
+// table by default.
+type output struct {
+	value interface{}
+	// header are the csv column names, upper cased in tables.
+	header []string
+	rows   [][]interface{}
+	// summary lines are printed after the table.
+	summary []string
+	text    func(w io.Writer)
+}
+
+func (o output) print(w io.Writer, format outputFormat) error {
+	switch format {
+	case OUTPUT_JSON:
+		valueJson, err := json.MarshalIndent(o.value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(valueJson))
+	case OUTPUT_CSV:
+		cw := csv.NewWriter(w)
+		cw.Write(o.header)
+		for _, row := range o.rows {
+			cw.Write(cells(row, OUTPUT_CSV))
+		}
+		cw.Flush()
+		return cw.Error()
+	case OUTPUT_TEXT:
+		if o.text != nil {
+			o.text(w)
+			return nil
+		}
+		fallthrough
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := make([]string, 0, len(o.header))
+		for _, column := range o.header {
+			header = append(header, strings.ToUpper(strings.Replace(column, "_", "-", -1)))
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range o.rows {
+			fmt.Fprintln(tw, strings.Join(cells(row, OUTPUT_TABLE), "\t"))
+		}
+		tw.Flush()
+
+		if len(o.summary) > 0 {
+			fmt.Fprintf(w, "\n%s\n", strings.Join(o.summary, "\n"))
+		}
+	}
+
+	return nil
+}
+
+// cells formats a row. Tables round prices and show missing values as a dash,
+// csv keeps the full precision and leaves them empty.
+func cells(row []interface{}, format outputFormat) []string {
+	formatted := make([]string, 0, len(row))
+
+	for _, value := range row {
+		var cell string
+
+		switch v := value.(type) {
+		case nil:
+			if format == OUTPUT_TABLE {
+				cell = "-"
+			}
+		case float64:
+			if format == OUTPUT_CSV {
+				cell = strconv.FormatFloat(v, 'f', -1, 64)
+			} else {
+				cell = fmt.Sprintf("%.2f", v)
+			}
+		case percent:
+			if format == OUTPUT_CSV {
+				cell = strconv.FormatFloat(float64(v), 'f', -1, 64)
+			} else {
+				cell = fmt.Sprintf("%.2f%%", float64(v)*100)
+			}
+		case time.Time:
+			if !v.IsZero() {
+				cell = v.Format(suggester.SNAPSHOT_DATE_LAYOUT)
+			}
+		default:
+			cell = fmt.Sprint(v)
+		}
+
+		formatted = append(formatted, cell)
+	}
+
+	return formatted
+}
+
+// counts formats counts by key sorted by key, as ARS:10 USD:2.
+func counts(byKey map[string]int) string {
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	formatted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		formatted = append(formatted, fmt.Sprintf("%s:%d", key, byKey[key]))
+	}
+
+	return strings.Join(formatted, " ")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/suggester"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressBarWidth is the width of the bar, in characters.
+const progressBarWidth = 30
+
+// progressFlag defines the -progress flag, on by default when stderr is a terminal.
+func progressFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("progress", isTerminal(os.Stderr), "Show a progress bar on stderr.")
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressBar renders the progress events of fetch and train on a single
+// line, redrawn on every event.
+type progressBar struct {
+	sync.Mutex
+	w     io.Writer
+	drawn bool
+}
+
+func newProgressBar(w io.Writer) *progressBar {
+	return &progressBar{w: w}
+}
+
+func (b *progressBar) Progress(event suggester.ProgressEvent) {
+	b.Lock()
+	defer b.Unlock()
+
+	// Carriage return and clear line, so the line is redrawn in place
+	fmt.Fprintf(b.w, "\r\x1b[K%s", progressLine(event))
+	b.drawn = true
+}
+
+// finish ends the line of the bar, if it was drawn.
+func (b *progressBar) finish() {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.drawn {
+		fmt.Fprintln(b.w)
+		b.drawn = false
+	}
+}
+
+// progressLine formats an event as: fetch [=====     ] 5/10 categories MLA1743 page 3, 150 items ETA 1m20s
+func progressLine(event suggester.ProgressEvent) string {
+	filled := 0
+	if event.Total > 0 {
+		filled = progressBarWidth * event.Done / event.Total
+	}
+
+	line := fmt.Sprintf("%s [%s%s] %d/%d categories", event.Op,
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), event.Done, event.Total)
+
+	switch event.Type {
+	case suggester.EVENT_PAGE_FETCHED:
+		line += fmt.Sprintf(" %s page %d, %d items", event.CategoryId, event.Pages, event.Items)
+	case suggester.EVENT_ITEMS_READ:
+		line += fmt.Sprintf(" %s %d items", event.CategoryId, event.Items)
+	case suggester.EVENT_CATEGORY_FINISHED:
+		if event.Error != "" {
+			line += fmt.Sprintf(" %s failed", event.CategoryId)
+		}
+	default:
+		line += " " + event.CategoryId
+	}
+
+	if event.EtaSeconds > 0 {
+		line += fmt.Sprintf(" ETA %s", (time.Duration(event.EtaSeconds) * time.Second).String())
+	}
+
+	return line
+}
@@ -0,0 +1,234 @@
+package suggester
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ADJUSTMENT_NONE       string = ""
+	ADJUSTMENT_TREND      string = "trend"
+	ADJUSTMENT_INDEX      string = "index"
+	PRICE_INDEX_FILE_PATH        = "./priceindex.csv"
+)
+
+// PriceAdjustment describes how a suggestion was adjusted from the snapshot date to today.
+type PriceAdjustment struct {
+	Method       string                 `json:"method"`
+	Factor       float64                `json:"factor"`
+	SnapshotDate time.Time              `json:"snapshot_date"`
+	AdjustedTo   time.Time              `json:"adjusted_to"`
+	Raw          CategoryPriceSuggested `json:"raw"`
+}
+
+// PriceIndex is a CPI-like series sorted by date.
+type PriceIndex []PriceIndexPoint
+
+type PriceIndexPoint struct {
+	Date  time.Time
+	Value float64
+}
+
+// SuggestAdjusted suggests a price for categoryId adjusted forward from the
+// snapshot date of the data set to today with method trend or index.
+func (s *Suggester) SuggestAdjusted(categoryId string, method string) (CategoryPriceSuggested, error) {
+	return s.suggestAdjusted(s.currentModel(), categoryId, method)
+}
+
+func (s *Suggester) suggestAdjusted(m model, categoryId string, method string) (CategoryPriceSuggested, error) {
+	suggested, err := m.suggest(categoryId)
+
+	if err != nil || method == ADJUSTMENT_NONE {
+		return suggested, err
+	}
+
+	snapshotDate := m.dataTrained.data[categoryId].SnapshotDate
+
+	if snapshotDate.IsZero() {
+		return suggested, newSuggesterError(ERR_INSUFFICIENT_DATA, fmt.Sprintf("Category: %s has no snapshot date, train the data set again to adjust prices.", categoryId))
+	}
+
+	now := s.now()
+
+	factor, err := adjustmentFactor(m, categoryId, method, snapshotDate, now)
+
+	if err != nil {
+		return suggested, err
+	}
+
+	adjusted := CategoryPriceSuggested{
+		Max:       suggested.Max * factor,
+		Suggested: suggested.Suggested * factor,
+		Min:       suggested.Min * factor,
+		Adjustment: &PriceAdjustment{
+			Method:       method,
+			Factor:       factor,
+			SnapshotDate: snapshotDate,
+			AdjustedTo:   now,
+			Raw:          suggested,
+		},
+	}
+
+	return adjusted, nil
+}
+
+// LoadPriceIndex loads the price index from the configured file, ./priceindex.csv
+// by default, and keep in memory.
+func (s *Suggester) LoadPriceIndex() error {
+	priceIndex, err := s.readPriceIndex()
+
+	if err != nil {
+		return err
+	}
+
+	s.SetPriceIndex(priceIndex)
+
+	return nil
+}
+
+func (s *Suggester) readPriceIndex() (PriceIndex, error) {
+	priceIndexPath := s.config.PriceIndexFile
+
+	priceIndex, err := ReadPriceIndexFile(priceIndexPath)
+
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("[LoadPriceIndex][Notice] Error reading price index file: %s", priceIndexPath))
+		s.logger.Debug(err)
+		return nil, err
+	}
+
+	return priceIndex, nil
+}
+
+func (s *Suggester) SetPriceIndex(priceIndex PriceIndex) {
+	s.updateModel(func(m *model) {
+		m.priceIndex = priceIndex
+	})
+}
+
+func adjustmentFactor(m model, categoryId string, method string, from time.Time, to time.Time) (float64, error) {
+	switch method {
+	case ADJUSTMENT_TREND:
+		trend, err := m.trend(categoryId)
+		if err != nil {
+			return 0, err
+		}
+		if _, fitted := fitMonthlyRate(trend.Series); !fitted {
+			return 0, newSuggesterError(ERR_INSUFFICIENT_DATA, fmt.Sprintf("Category: %s has less than 2 snapshots with prices to fit a trend.", categoryId))
+		}
+		return trendFactor(trend.MonthlyRate, from, to), nil
+
+	case ADJUSTMENT_INDEX:
+		if m.priceIndex == nil {
+			return 0, newSuggesterError(ERR_INSUFFICIENT_DATA, "Price index not loaded.")
+		}
+		return m.priceIndex.Factor(from, to)
+
+	default:
+		return 0, newSuggesterError(ERR_INVALID_REQUEST, fmt.Sprintf("Adjustment method: %s is not supported.", method))
+	}
+}
+
+// trendFactor compounds a monthly rate of change between two dates.
+func trendFactor(monthlyRate float64, from time.Time, to time.Time) float64 {
+	months := to.Sub(from).Hours() / 24 / DAYS_PER_MONTH
+
+	return math.Pow(1+monthlyRate, months)
+}
+
+// ReadPriceIndexFile reads a price index from a CSV file with lines
+// "date,value", where date is YYYY-MM-DD or YYYY-MM. A header line is allowed.
+func ReadPriceIndexFile(path string) (PriceIndex, error) {
+	var priceIndex PriceIndex
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for index, record := range records {
+		if len(record) < 2 {
+			return nil, errors.New(fmt.Sprintf("Price index line %d must have date and value.", index+1))
+		}
+
+		date, dateErr := parseIndexDate(strings.TrimSpace(record[0]))
+		value, valueErr := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+
+		if dateErr != nil || valueErr != nil {
+			// Header line
+			if index == 0 {
+				continue
+			}
+			return nil, errors.New(fmt.Sprintf("Price index line %d is invalid: %s", index+1, strings.Join(record, ",")))
+		}
+
+		priceIndex = append(priceIndex, PriceIndexPoint{Date: date, Value: value})
+	}
+
+	if len(priceIndex) == 0 {
+		return nil, errors.New(fmt.Sprintf("Price index file: %s is empty.", path))
+	}
+
+	sort.Slice(priceIndex, func(i, j int) bool {
+		return priceIndex[i].Date.Before(priceIndex[j].Date)
+	})
+
+	return priceIndex, nil
+}
+
+// ValueAt returns the latest index value published on or before date.
+func (p PriceIndex) ValueAt(date time.Time) (float64, bool) {
+	position := sort.Search(len(p), func(i int) bool {
+		return p[i].Date.After(date)
+	})
+
+	if position == 0 {
+		return 0, false
+	}
+
+	return p[position-1].Value, true
+}
+
+// Factor returns the ratio between the index values at to and from. It fails
+// when the index has no value at either date.
+func (p PriceIndex) Factor(from time.Time, to time.Time) (float64, error) {
+	fromValue, ok := p.ValueAt(from)
+
+	if !ok || fromValue == 0 {
+		return 0, newSuggesterError(ERR_INSUFFICIENT_DATA, fmt.Sprintf("Price index has no value for: %s", from.Format(SNAPSHOT_DATE_LAYOUT)))
+	}
+
+	toValue, ok := p.ValueAt(to)
+
+	if !ok {
+		return 0, newSuggesterError(ERR_INSUFFICIENT_DATA, fmt.Sprintf("Price index has no value for: %s", to.Format(SNAPSHOT_DATE_LAYOUT)))
+	}
+
+	return toValue / fromValue, nil
+}
+
+func parseIndexDate(value string) (time.Time, error) {
+	date, err := time.Parse(SNAPSHOT_DATE_LAYOUT, value)
+
+	if err != nil {
+		return time.Parse("2006-01", value)
+	}
+
+	return date, nil
+}
@@ -0,0 +1,89 @@
+package suggester
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadPriceIndexFile(t *testing.T) {
+	indexFile, _ := ioutil.TempFile("", "priceindex")
+	defer os.Remove(indexFile.Name())
+
+	indexFile.WriteString("date,value\n2018-02,110\n2018-01-01,100\n")
+	indexFile.Close()
+
+	priceIndex, err := ReadPriceIndexFile(indexFile.Name())
+
+	assert.Nil(t, err)
+	if assert.Len(t, priceIndex, 2) {
+		t.Log("Given a CSV price index, ReadPriceIndexFile returns it sorted by date.", checkMark)
+		assert.Equal(t, 100.0, priceIndex[0].Value)
+	}
+
+	factor, err := priceIndex.Factor(time.Date(2018, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	t.Log("Given an index from 100 to 110, Factor returns 1.1", checkMark)
+	assert.Nil(t, err)
+	assert.InDelta(t, 1.1, factor, 0.0001)
+
+	_, err = priceIndex.Factor(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), time.Now())
+
+	t.Log("Given a date before the index, Factor returns error.", checkMark)
+	assert.NotNil(t, err)
+
+	_, err = priceIndex.Factor(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	t.Log("Given a date to adjust to before the index, Factor returns error.", checkMark)
+	assert.Equal(t, ERR_INSUFFICIENT_DATA, ErrorCode(err))
+}
+
+func TestTrendFactor(t *testing.T) {
+	from := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Duration(2*DAYS_PER_MONTH*24) * time.Hour)
+
+	t.Log("Given a monthly rate of 10% over two months, trendFactor returns 1.21", checkMark)
+	assert.InDelta(t, 1.21, trendFactor(0.1, from, to), 0.0001)
+}
+
+func TestSuggester_SuggestAdjusted(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	snapshotDate := time.Now().AddDate(0, -1, 0)
+
+	s := NewSuggester(config)
+	s.SetInMemoryDataTrained(map[string]CategoryPriceTrained{
+		CategoryIdTest: {Max: 100, Suggested: 90, Min: 60, SnapshotDate: snapshotDate},
+	})
+	s.SetPriceIndex(PriceIndex{
+		{Date: snapshotDate.AddDate(0, 0, -1), Value: 100},
+		{Date: snapshotDate.AddDate(0, 0, 1), Value: 120},
+	})
+
+	suggested, err := s.SuggestAdjusted(CategoryIdTest, ADJUSTMENT_INDEX)
+
+	assert.Nil(t, err)
+	if assert.NotNil(t, suggested.Adjustment) {
+		t.Log("Given an index from 100 to 120, SuggestAdjusted returns raw and adjusted prices.", checkMark)
+		assert.InDelta(t, 1.2, suggested.Adjustment.Factor, 0.0001)
+		assert.InDelta(t, 108, suggested.Suggested, 0.0001)
+		assert.Equal(t, 90.0, suggested.Adjustment.Raw.Suggested)
+	}
+
+	s.SetInMemoryPriceHistory(map[string][]PricePoint{
+		CategoryIdTest: {{Date: snapshotDate, Median: 90, Total: 10}},
+	})
+
+	_, err = s.SuggestAdjusted(CategoryIdTest, ADJUSTMENT_TREND)
+
+	t.Log("Given a single snapshot, SuggestAdjusted by trend returns insufficient data.", checkMark)
+	assert.Equal(t, ERR_INSUFFICIENT_DATA, ErrorCode(err))
+
+	_, err = s.SuggestAdjusted(CategoryIdTest, "unknown")
+
+	t.Log("Given an unknown method, SuggestAdjusted returns error.", checkMark)
+	assert.NotNil(t, err)
+}
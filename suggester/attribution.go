@@ -0,0 +1,106 @@
+package suggester
+
+import (
+	"fmt"
+)
+
+const (
+	// ATTRIBUTION_FOLDER trains every item into the category of its data set folder.
+	ATTRIBUTION_FOLDER string = "folder"
+	// ATTRIBUTION_ITEM trains every item into the category_id it reports.
+	ATTRIBUTION_ITEM string = "item"
+	// ATTRIBUTION_TREE trains every item into the category_id it reports and
+	// every ancestor of it, the data set folder included.
+	ATTRIBUTION_TREE string = "tree"
+)
+
+// categoryTree maps each category to its parent, as far as the data set knows them.
+type categoryTree map[string]string
+
+// attribution decides the categories an item of a data set folder is trained into.
+type attribution struct {
+	policy string
+	tree   categoryTree
+}
+
+func (a attribution) categories(folder string, categoryId string) []string {
+	switch a.policy {
+	case ATTRIBUTION_ITEM:
+		return []string{categoryId}
+	case ATTRIBUTION_TREE:
+		return append(a.tree.pathToAncestor(categoryId, folder), a.tree.ancestors(folder)...)
+	default:
+		return []string{folder}
+	}
+}
+
+// ancestors returns the parent of categoryId, its parent and so on up to the root.
+func (t categoryTree) ancestors(categoryId string) []string {
+	var ancestors []string
+
+	for parent, ok := t[categoryId]; ok && len(ancestors) <= len(t); parent, ok = t[parent] {
+		ancestors = append(ancestors, parent)
+	}
+
+	return ancestors
+}
+
+// pathToAncestor returns categoryId and its ancestors up to ancestor, inclusive.
+// The items of a category search belong to it or to a descendant of it, so
+// ancestor is kept as the parent of categoryId when the tree does not know better.
+func (t categoryTree) pathToAncestor(categoryId string, ancestor string) []string {
+	path := []string{categoryId}
+
+	if categoryId == ancestor {
+		return path
+	}
+
+	ancestors := t.ancestors(categoryId)
+
+	for index, parent := range ancestors {
+		if parent == ancestor {
+			return append(path, ancestors[:index+1]...)
+		}
+	}
+
+	return append(path, ancestor)
+}
+
+// path returns the categories from the root to categoryId.
+func (t categoryTree) path(categoryId string) []string {
+	ancestors := t.ancestors(categoryId)
+	path := make([]string, 0, len(ancestors)+1)
+
+	for index := len(ancestors) - 1; index >= 0; index-- {
+		path = append(path, ancestors[index])
+	}
+
+	return append(path, categoryId)
+}
+
+// loadCategoryTree builds the tree from the category path recorded when each
+// data set folder was fetched. Folders fetched without it are left out.
+func (s *Suggester) loadCategoryTree(categories []string) categoryTree {
+	tree := make(categoryTree)
+
+	for _, categoryId := range categories {
+		snapshotDate, ok, err := latestSnapshotDate(s.storage, categoryId)
+
+		if err != nil || !ok {
+			continue
+		}
+
+		info, err := s.storage.ReadSnapshotInfo(categoryId, snapshotDate)
+
+		if err != nil {
+			s.logger.Debug(fmt.Sprintf("[loadCategoryTree] Category path of: %s unknown.", categoryId))
+			continue
+		}
+
+		for index := 1; index < len(info.CategoryPath); index++ {
+			tree[info.CategoryPath[index]] = info.CategoryPath[index-1]
+		}
+	}
+
+	return tree
+}
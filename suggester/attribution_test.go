@@ -0,0 +1,114 @@
+package suggester
+
+import (
+	"encoding/json"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	CategoryIdAttributionRoot       string = "MLA999009"
+	CategoryIdAttributionParent     string = "MLA999010"
+	CategoryIdAttributionChild      string = "MLA999011"
+	CategoryIdAttributionSibling    string = "MLA999012"
+	CategoryIdAttributionGrandchild string = "MLA999013"
+)
+
+func TestAttribution_Categories(t *testing.T) {
+	tree := categoryTree{
+		CategoryIdAttributionParent: CategoryIdAttributionRoot,
+		CategoryIdAttributionChild:  CategoryIdAttributionParent,
+	}
+
+	folder := attribution{policy: ATTRIBUTION_FOLDER, tree: tree}
+	t.Log("Given the folder policy, items are trained into the folder category.", checkMark)
+	assert.Equal(t, []string{CategoryIdAttributionParent}, folder.categories(CategoryIdAttributionParent, CategoryIdAttributionChild))
+
+	item := attribution{policy: ATTRIBUTION_ITEM, tree: tree}
+	t.Log("Given the item policy, items are trained into the category they report.", checkMark)
+	assert.Equal(t, []string{CategoryIdAttributionChild}, item.categories(CategoryIdAttributionParent, CategoryIdAttributionChild))
+
+	both := attribution{policy: ATTRIBUTION_TREE, tree: tree}
+	t.Log("Given the tree policy, items are trained into their category and every ancestor.", checkMark)
+	assert.Equal(t, []string{CategoryIdAttributionChild, CategoryIdAttributionParent, CategoryIdAttributionRoot},
+		both.categories(CategoryIdAttributionParent, CategoryIdAttributionChild))
+	assert.Equal(t, []string{CategoryIdAttributionGrandchild, CategoryIdAttributionChild, CategoryIdAttributionParent, CategoryIdAttributionRoot},
+		both.categories(CategoryIdAttributionChild, CategoryIdAttributionGrandchild))
+}
+
+func writeAttributionTestDataSet(s *Suggester, folder string, path []string, items ...meli.SearchItem) {
+	snapshot := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshotFolder := filepath.Join(s.config.DataSetPath, folder, snapshot.Format(SNAPSHOT_DATE_LAYOUT))
+	os.MkdirAll(snapshotFolder, 0777)
+
+	itemsJson, _ := json.Marshal(items)
+	ioutil.WriteFile(filepath.Join(snapshotFolder, folder+"-0.json"), itemsJson, 0777)
+
+	s.storage.WriteSnapshotInfo(folder, snapshot, SnapshotInfo{CategoryPath: path})
+}
+
+func TestSuggester_TrainAttribution(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := NewSuggester(config)
+
+	// The parent search returns items of the child category too
+	writeAttributionTestDataSet(s, CategoryIdAttributionParent,
+		[]string{CategoryIdAttributionRoot, CategoryIdAttributionParent},
+		meli.SearchItem{Id: "MLA1", Price: 10, CategoryId: CategoryIdAttributionParent},
+		meli.SearchItem{Id: "MLA2", Price: 30, CategoryId: CategoryIdAttributionChild})
+	writeAttributionTestDataSet(s, CategoryIdAttributionChild,
+		[]string{CategoryIdAttributionRoot, CategoryIdAttributionParent, CategoryIdAttributionChild},
+		meli.SearchItem{Id: "MLA3", Price: 100, CategoryId: CategoryIdAttributionChild},
+		meli.SearchItem{Id: "MLA4", Price: 200, CategoryId: CategoryIdAttributionGrandchild})
+	writeAttributionTestDataSet(s, CategoryIdAttributionSibling,
+		[]string{CategoryIdAttributionRoot, CategoryIdAttributionSibling},
+		meli.SearchItem{Id: "MLA5", Price: 50, CategoryId: CategoryIdAttributionSibling})
+
+	s.TrainWithOptions(TrainOptions{Attribution: ATTRIBUTION_FOLDER})
+
+	t.Log("Given the folder policy, only fetched categories are trained, each from its folder.", checkMark)
+	{
+		suggested, _ := s.Suggest(CategoryIdAttributionParent)
+		assert.Equal(t, 20.0, suggested.Suggested)
+
+		suggested, _ = s.Suggest(CategoryIdAttributionChild)
+		assert.Equal(t, 150.0, suggested.Suggested)
+
+		_, err := s.Suggest(CategoryIdAttributionGrandchild)
+		assert.NotNil(t, err)
+	}
+
+	s.TrainWithOptions(TrainOptions{Attribution: ATTRIBUTION_TREE})
+
+	t.Log("Given the tree policy, ancestors aggregate their outermost folders once.", checkMark)
+	{
+		suggested, _ := s.Suggest(CategoryIdAttributionParent)
+		assert.Equal(t, 20.0, suggested.Suggested)
+
+		suggested, _ = s.Suggest(CategoryIdAttributionGrandchild)
+		assert.Equal(t, 200.0, suggested.Suggested)
+
+		// Root is merged from the parent and sibling folders, not the nested child folder
+		suggested, _ = s.Suggest(CategoryIdAttributionRoot)
+		assert.Equal(t, 30.0, suggested.Suggested)
+		assert.Equal(t, 50.0, suggested.Max)
+	}
+
+	info, _ := s.ModelInfo()
+
+	t.Log("Given the tree policy, the model counts each item trained once.", checkMark)
+	assert.Equal(t, 5, info.Items)
+
+	manifest := s.loadTrainManifest()
+
+	t.Log("The manifest records the attribution policy it was trained with.", checkMark)
+	assert.Equal(t, ATTRIBUTION_TREE, manifest.Attribution)
+	assert.Equal(t, []string{CategoryIdAttributionRoot, CategoryIdAttributionParent, CategoryIdAttributionChild}, manifest.Folders[CategoryIdAttributionChild].CategoryPath)
+}
@@ -0,0 +1,161 @@
+package suggester
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	SCOPE_READ  string = "read"
+	SCOPE_ADMIN string = "admin"
+	// API_KEY_HEADER carries the API key, otherwise it is read from an Authorization: Bearer header.
+	API_KEY_HEADER string = "X-Api-Key"
+	// API_KEY_CONTEXT_KEY is the key of the authenticated *ApiKey in the gin context.
+	API_KEY_CONTEXT_KEY string = "apiKey"
+)
+
+// ApiKey is a client of the API, named in logs and rate limits. Admin scope
+// includes read. RateLimit and RateBurst override the server ones when set.
+type ApiKey struct {
+	Name      string   `yaml:"name"`
+	Key       string   `yaml:"key"`
+	Scopes    []string `yaml:"scopes"`
+	RateLimit float64  `yaml:"rate_limit"`
+	RateBurst int      `yaml:"rate_burst"`
+}
+
+// ApiKeys are the keys clients authenticate with.
+type ApiKeys struct {
+	Keys []ApiKey `yaml:"keys"`
+}
+
+// LoadApiKeys reads the API keys from a YAML file.
+func LoadApiKeys(path string) (ApiKeys, error) {
+	var keys ApiKeys
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return keys, err
+	}
+
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return keys, errors.New(fmt.Sprintf("Api keys file: %s %s", path, err))
+	}
+
+	if err := keys.Validate(); err != nil {
+		return keys, errors.New(fmt.Sprintf("Api keys file: %s %s", path, err))
+	}
+
+	return keys, nil
+}
+
+// Validate checks every key has a unique name and key and known scopes.
+func (k ApiKeys) Validate() error {
+	names := make(map[string]bool)
+	keys := make(map[string]bool)
+
+	for _, apiKey := range k.Keys {
+		switch {
+		case apiKey.Name == "" || apiKey.Key == "":
+			return errors.New("Api key name and key mustn't be empty.")
+		case names[apiKey.Name] || keys[apiKey.Key]:
+			return errors.New(fmt.Sprintf("Api key: %s is duplicated.", apiKey.Name))
+		case apiKey.RateLimit < 0 || apiKey.RateBurst < 0:
+			return errors.New(fmt.Sprintf("Api key: %s rate limit and burst mustn't be negative.", apiKey.Name))
+		}
+
+		for _, scope := range apiKey.Scopes {
+			if scope != SCOPE_READ && scope != SCOPE_ADMIN {
+				return errors.New(fmt.Sprintf("Api key: %s scope: %s is not read or admin.", apiKey.Name, scope))
+			}
+		}
+
+		names[apiKey.Name] = true
+		keys[apiKey.Key] = true
+	}
+
+	return nil
+}
+
+// lookup returns the API key of key. Every key is compared in constant time.
+func (k ApiKeys) lookup(key string) (*ApiKey, bool) {
+	var found *ApiKey
+
+	for index := range k.Keys {
+		if subtle.ConstantTimeCompare([]byte(k.Keys[index].Key), []byte(key)) == 1 {
+			found = &k.Keys[index]
+		}
+	}
+
+	return found, found != nil
+}
+
+// HasScope reports whether the key is granted scope.
+func (k *ApiKey) HasScope(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == SCOPE_ADMIN {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticate rejects the requests without a known API key with 401. Every
+// request is let through when there are no keys.
+func Authenticate(keys ApiKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(keys.Keys) == 0 {
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(API_KEY_HEADER)
+		if authorization := c.GetHeader("Authorization"); key == "" && strings.HasPrefix(authorization, "Bearer ") {
+			key = strings.TrimPrefix(authorization, "Bearer ")
+		}
+
+		apiKey, ok := keys.lookup(key)
+
+		if !ok {
+			c.Header("WWW-Authenticate", "Bearer")
+			abortWithError(c, newSuggesterError(ERR_UNAUTHORIZED, "Api key missing or unknown."))
+			return
+		}
+
+		c.Set(API_KEY_CONTEXT_KEY, apiKey)
+		c.Next()
+	}
+}
+
+// RequireScope rejects with 403 the requests whose API key is not granted
+// scope. Requests are not checked when the API is open.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey, ok := requestApiKey(c); ok && !apiKey.HasScope(scope) {
+			abortWithError(c, newSuggesterError(ERR_FORBIDDEN, fmt.Sprintf("Api key: %s has no %s scope.", apiKey.Name, scope)))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requestApiKey returns the API key the request was authenticated with.
+func requestApiKey(c *gin.Context) (*ApiKey, bool) {
+	value, ok := c.Get(API_KEY_CONTEXT_KEY)
+
+	if !ok {
+		return nil, false
+	}
+
+	apiKey, ok := value.(*ApiKey)
+
+	return apiKey, ok
+}
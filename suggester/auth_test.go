@@ -0,0 +1,95 @@
+package suggester
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const apiKeysTest = `keys:
+  - name: reader
+    key: reader-key
+    scopes: [read]
+  - name: operator
+    key: operator-key
+    scopes: [admin]
+`
+
+func TestLoadApiKeys(t *testing.T) {
+	folder, _ := ioutil.TempDir("", "apikeys")
+	defer os.RemoveAll(folder)
+
+	path := filepath.Join(folder, "apikeys.yaml")
+
+	t.Log("Given a keys file, it loads the keys.", checkMark)
+	{
+		ioutil.WriteFile(path, []byte(apiKeysTest), 0644)
+
+		keys, err := LoadApiKeys(path)
+		assert.Nil(t, err)
+		assert.Len(t, keys.Keys, 2)
+		assert.Equal(t, []string{SCOPE_READ}, keys.Keys[0].Scopes)
+	}
+
+	t.Log("Given an unknown scope or a duplicated key, it fails.", checkMark)
+	{
+		ioutil.WriteFile(path, []byte("keys:\n  - {name: a, key: k, scopes: [write]}\n"), 0644)
+		_, err := LoadApiKeys(path)
+		assert.NotNil(t, err)
+
+		ioutil.WriteFile(path, []byte("keys:\n  - {name: a, key: k}\n  - {name: b, key: k}\n"), 0644)
+		_, err = LoadApiKeys(path)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	keys := ApiKeys{Keys: []ApiKey{
+		{Name: "reader", Key: "reader-key", Scopes: []string{SCOPE_READ}},
+		{Name: "operator", Key: "operator-key", Scopes: []string{SCOPE_ADMIN}},
+		{Name: "none", Key: "none-key"},
+	}}
+
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(keys ApiKeys) *gin.Engine {
+		router := gin.New()
+		group := router.Group("/", Authenticate(keys))
+		group.GET("/read", RequireScope(SCOPE_READ), func(c *gin.Context) { c.String(http.StatusOK, "read") })
+		group.GET("/admin", RequireScope(SCOPE_ADMIN), func(c *gin.Context) { c.String(http.StatusOK, "admin") })
+		return router
+	}
+
+	get := func(router *gin.Engine, url string, header string, value string) int {
+		req, _ := http.NewRequest("GET", url, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	router := newRouter(keys)
+
+	t.Log("Given no key or an unknown one, it returns 401.", checkMark)
+	assert.Equal(t, http.StatusUnauthorized, get(router, "/read", "", ""))
+	assert.Equal(t, http.StatusUnauthorized, get(router, "/read", API_KEY_HEADER, "other-key"))
+
+	t.Log("Given a key, by header or bearer token, it is granted its scopes.", checkMark)
+	assert.Equal(t, http.StatusOK, get(router, "/read", API_KEY_HEADER, "reader-key"))
+	assert.Equal(t, http.StatusOK, get(router, "/read", "Authorization", "Bearer operator-key"))
+	assert.Equal(t, http.StatusOK, get(router, "/admin", API_KEY_HEADER, "operator-key"))
+
+	t.Log("Given a key without the scope, it returns 403.", checkMark)
+	assert.Equal(t, http.StatusForbidden, get(router, "/admin", API_KEY_HEADER, "reader-key"))
+	assert.Equal(t, http.StatusForbidden, get(router, "/read", API_KEY_HEADER, "none-key"))
+
+	t.Log("Given no keys, the API is open.", checkMark)
+	assert.Equal(t, http.StatusOK, get(newRouter(ApiKeys{}), "/admin", "", ""))
+}
@@ -0,0 +1,65 @@
+package suggester
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// modelETag is the entity tag of a resource derived from the data of version,
+// the model or the price history, so it changes when other data is loaded.
+func modelETag(version string, resource string) string {
+	return fmt.Sprintf("\"%s-%s\"", version, resource)
+}
+
+// notModified sets the caching headers of a resource derived from the data of
+// version, updated at updatedAt, and reports whether the copy of the client
+// is still valid, in which case the response is a 304 without body.
+func (s *SuggesterCtrl) notModified(c *gin.Context, version string, updatedAt time.Time, resource string) bool {
+	if version == "" {
+		return false
+	}
+
+	etag := modelETag(version, resource)
+	lastModified := updatedAt.UTC().Truncate(time.Second)
+
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+	if cacheControl := s.Suggester.config.Server.CacheControl; cacheControl != "" {
+		c.Header("Cache-Control", cacheControl)
+	}
+
+	if !isNotModified(c.Request, etag, lastModified) {
+		return false
+	}
+
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// isNotModified evaluates If-None-Match, or If-Modified-Since when the former
+// is not given, as RFC 7232 does.
+func isNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+
+	return err == nil && !lastModified.After(ifModifiedSince)
+}
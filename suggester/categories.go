@@ -0,0 +1,272 @@
+package suggester
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	CATEGORY_SORT_ID          string = "id"
+	CATEGORY_SORT_SAMPLE_SIZE string = "sample_size"
+	DEFAULT_CATEGORY_LIMIT           = 50
+	MAX_CATEGORY_LIMIT               = 200
+)
+
+// categoryIdPattern is a site id, three upper case letters, followed by digits, as MLA1051.
+var categoryIdPattern = regexp.MustCompile(`^[A-Z]{3}[0-9]+$`)
+
+// ValidateCategoryId checks categoryId is a category id of site, so malformed
+// ids are rejected before looking them up.
+func ValidateCategoryId(site string, categoryId string) error {
+	switch {
+	case categoryId == "":
+		return newSuggesterError(ERR_INVALID_CATEGORY_ID, "Category id is empty.")
+	case !categoryIdPattern.MatchString(categoryId):
+		return newSuggesterError(ERR_INVALID_CATEGORY_ID,
+			fmt.Sprintf("Category id: %s is not a site id followed by digits, as %s1051.", categoryId, site))
+	case siteOf(categoryId) != site:
+		return newSuggesterError(ERR_INVALID_CATEGORY_ID,
+			fmt.Sprintf("Category id: %s is not of site %s.", categoryId, site))
+	}
+
+	return nil
+}
+
+// ValidateCategoryId checks categoryId is a category id of the configured site.
+func (s *Suggester) ValidateCategoryId(categoryId string) error {
+	return ValidateCategoryId(s.config.Site, categoryId)
+}
+
+// CategoryQuery selects a page of the categories of the model.
+type CategoryQuery struct {
+	Site string
+	// Parent selects the direct children of a category.
+	Parent string
+	// Name selects the categories whose name contains it, case insensitive.
+	Name string
+	// Sort is id or sample_size, prefixed with - for descending order. By id if empty.
+	Sort   string
+	Offset int
+	// Limit is DEFAULT_CATEGORY_LIMIT if zero, up to MAX_CATEGORY_LIMIT.
+	Limit int
+}
+
+// CategoryPage is a page of the categories of the model, paged as the Meli search.
+type CategoryPage struct {
+	Paging  CategoryPaging    `json:"paging"`
+	Results []CategorySummary `json:"results"`
+}
+
+type CategoryPaging struct {
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+// CategorySummary is a category of the model and its suggested prices.
+type CategorySummary struct {
+	Id         string  `json:"id"`
+	Name       string  `json:"name,omitempty"`
+	Site       string  `json:"site"`
+	Parent     string  `json:"parent,omitempty"`
+	SampleSize int     `json:"sample_size"`
+	Suggested  float64 `json:"suggested"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+}
+
+// CategoryDetail is a category of the model, its place in the category tree
+// and its trained statistics.
+type CategoryDetail struct {
+	Id   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Site string `json:"site"`
+	// Path is the categories from the root to the category, inclusive.
+	Path     []CategoryRef `json:"path"`
+	Children []CategoryRef `json:"children"`
+	Stats    CategoryStats `json:"stats"`
+}
+
+// CategoryRef is a category of the tree, trained if it has suggestions.
+type CategoryRef struct {
+	Id      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Trained bool   `json:"trained"`
+}
+
+// CategoryStats are the statistics of a category in the model.
+type CategoryStats struct {
+	Suggested    float64   `json:"suggested"`
+	Min          float64   `json:"min"`
+	Max          float64   `json:"max"`
+	Sum          float64   `json:"sum"`
+	SampleSize   int       `json:"sample_size"`
+	SnapshotDate time.Time `json:"snapshot_date"`
+}
+
+// categoryTree returns the tree and the names of the categories recorded by
+// the folders of the manifest.
+func (m TrainManifest) categoryTree() (categoryTree, map[string]string) {
+	tree := make(categoryTree)
+	names := make(map[string]string)
+
+	for _, folder := range m.Folders {
+		for index := 1; index < len(folder.CategoryPath); index++ {
+			tree[folder.CategoryPath[index]] = folder.CategoryPath[index-1]
+		}
+
+		for categoryId, name := range folder.CategoryNames {
+			names[categoryId] = name
+		}
+	}
+
+	return tree, names
+}
+
+// snapshotCategoryNames returns the category names recorded when the latest snapshot of categoryId was fetched.
+func (s *Suggester) snapshotCategoryNames(categoryId string) map[string]string {
+	snapshotDate, ok, err := latestSnapshotDate(s.storage, categoryId)
+
+	if err != nil || !ok {
+		return nil
+	}
+
+	info, err := s.storage.ReadSnapshotInfo(categoryId, snapshotDate)
+
+	if err != nil {
+		return nil
+	}
+
+	return info.CategoryNames
+}
+
+func (d *DataTrained) categoryRef(categoryId string) CategoryRef {
+	_, trained := d.data[categoryId]
+
+	return CategoryRef{Id: categoryId, Name: d.names[categoryId], Trained: trained}
+}
+
+// Categories returns the page of the categories of the model selected by query.
+func (s *Suggester) Categories(query CategoryQuery) (CategoryPage, error) {
+	dataTrained := s.GetInMemoryDataTrained()
+
+	if dataTrained == nil {
+		return CategoryPage{}, ErrModelNotLoaded
+	}
+
+	descending := strings.HasPrefix(query.Sort, "-")
+	sortBy := strings.TrimPrefix(query.Sort, "-")
+
+	switch {
+	case sortBy != "" && sortBy != CATEGORY_SORT_ID && sortBy != CATEGORY_SORT_SAMPLE_SIZE:
+		return CategoryPage{}, newSuggesterError(ERR_INVALID_REQUEST, fmt.Sprintf("Sort: %s is not id or sample_size.", query.Sort))
+	case query.Offset < 0 || query.Limit < 0:
+		return CategoryPage{}, newSuggesterError(ERR_INVALID_REQUEST, "Offset and limit mustn't be negative.")
+	case query.Limit == 0:
+		query.Limit = DEFAULT_CATEGORY_LIMIT
+	case query.Limit > MAX_CATEGORY_LIMIT:
+		query.Limit = MAX_CATEGORY_LIMIT
+	}
+
+	dataTrained.RLock()
+	defer dataTrained.RUnlock()
+
+	name := strings.ToLower(query.Name)
+	categories := []CategorySummary{}
+
+	for categoryId, trained := range dataTrained.data {
+		category := CategorySummary{
+			Id:         categoryId,
+			Name:       dataTrained.names[categoryId],
+			Site:       siteOf(categoryId),
+			Parent:     dataTrained.tree[categoryId],
+			SampleSize: int(trained.Total),
+			Suggested:  trained.Suggested,
+			Min:        trained.Min,
+			Max:        trained.Max,
+		}
+
+		if (query.Site != "" && category.Site != query.Site) ||
+			(query.Parent != "" && category.Parent != query.Parent) ||
+			(name != "" && !strings.Contains(strings.ToLower(category.Name), name)) {
+			continue
+		}
+
+		categories = append(categories, category)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if sortBy == CATEGORY_SORT_SAMPLE_SIZE && categories[i].SampleSize != categories[j].SampleSize {
+			return (categories[i].SampleSize < categories[j].SampleSize) != descending
+		}
+		return (categories[i].Id < categories[j].Id) != (descending && sortBy == CATEGORY_SORT_ID)
+	})
+
+	page := CategoryPage{
+		Paging:  CategoryPaging{Total: len(categories), Offset: query.Offset, Limit: query.Limit},
+		Results: []CategorySummary{},
+	}
+
+	if query.Offset < len(categories) {
+		end := query.Offset + query.Limit
+		if end > len(categories) {
+			end = len(categories)
+		}
+		page.Results = categories[query.Offset:end]
+	}
+
+	return page, nil
+}
+
+// Category returns a category of the model with its path, children and trained statistics.
+func (s *Suggester) Category(categoryId string) (CategoryDetail, error) {
+	dataTrained := s.GetInMemoryDataTrained()
+
+	if dataTrained == nil {
+		return CategoryDetail{}, ErrModelNotLoaded
+	}
+
+	dataTrained.RLock()
+	defer dataTrained.RUnlock()
+
+	trained, ok := dataTrained.data[categoryId]
+
+	if !ok {
+		return CategoryDetail{}, errCategoryNotFound(categoryId)
+	}
+
+	detail := CategoryDetail{
+		Id:       categoryId,
+		Name:     dataTrained.names[categoryId],
+		Site:     siteOf(categoryId),
+		Path:     []CategoryRef{},
+		Children: []CategoryRef{},
+		Stats: CategoryStats{
+			Suggested:    trained.Suggested,
+			Min:          trained.Min,
+			Max:          trained.Max,
+			Sum:          trained.Sum,
+			SampleSize:   int(trained.Total),
+			SnapshotDate: trained.SnapshotDate,
+		},
+	}
+
+	for _, ancestor := range dataTrained.tree.path(categoryId) {
+		detail.Path = append(detail.Path, dataTrained.categoryRef(ancestor))
+	}
+
+	for child, parent := range dataTrained.tree {
+		if parent == categoryId {
+			detail.Children = append(detail.Children, dataTrained.categoryRef(child))
+		}
+	}
+
+	sort.Slice(detail.Children, func(i, j int) bool {
+		return detail.Children[i].Id < detail.Children[j].Id
+	})
+
+	return detail, nil
+}
@@ -0,0 +1,160 @@
+package suggester
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newCategoriesTestSuggester(config Config) *Suggester {
+	s := NewSuggester(config)
+
+	writeAttributionTestDataSet(s, CategoryIdAttributionParent,
+		[]string{CategoryIdAttributionRoot, CategoryIdAttributionParent},
+		meli.SearchItem{Id: "MLA1", Price: 10, CategoryId: CategoryIdAttributionParent},
+		meli.SearchItem{Id: "MLA2", Price: 30, CategoryId: CategoryIdAttributionParent})
+	writeAttributionTestDataSet(s, CategoryIdAttributionSibling,
+		[]string{CategoryIdAttributionRoot, CategoryIdAttributionSibling},
+		meli.SearchItem{Id: "MLA3", Price: 50, CategoryId: CategoryIdAttributionSibling})
+
+	snapshot := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.storage.WriteSnapshotInfo(CategoryIdAttributionParent, snapshot, SnapshotInfo{
+		CategoryPath:  []string{CategoryIdAttributionRoot, CategoryIdAttributionParent},
+		CategoryNames: map[string]string{CategoryIdAttributionRoot: "Electrónica", CategoryIdAttributionParent: "Celulares"},
+	})
+
+	s.TrainWithOptions(TrainOptions{Attribution: ATTRIBUTION_TREE})
+
+	return s
+}
+
+func TestSuggester_Categories(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := newCategoriesTestSuggester(config)
+
+	t.Log("Given no query, every category is returned by id.", checkMark)
+	{
+		page, err := s.Categories(CategoryQuery{})
+		assert.Nil(t, err)
+		assert.Equal(t, CategoryPaging{Total: 3, Offset: 0, Limit: DEFAULT_CATEGORY_LIMIT}, page.Paging)
+		assert.Equal(t, CategoryIdAttributionRoot, page.Results[0].Id)
+		assert.Equal(t, "Electrónica", page.Results[0].Name)
+		assert.Equal(t, 3, page.Results[0].SampleSize)
+	}
+
+	t.Log("Given a parent, its children are returned sorted by sample size.", checkMark)
+	{
+		page, _ := s.Categories(CategoryQuery{Parent: CategoryIdAttributionRoot, Sort: "-sample_size"})
+		assert.Equal(t, 2, page.Paging.Total)
+		assert.Equal(t, CategoryIdAttributionParent, page.Results[0].Id)
+		assert.Equal(t, CategoryIdAttributionSibling, page.Results[1].Id)
+	}
+
+	t.Log("Given a name, the categories whose name contains it are returned.", checkMark)
+	{
+		page, _ := s.Categories(CategoryQuery{Name: "celu"})
+		assert.Equal(t, 1, page.Paging.Total)
+		assert.Equal(t, CategoryIdAttributionParent, page.Results[0].Id)
+	}
+
+	t.Log("Given an offset and limit, a page is returned.", checkMark)
+	{
+		page, _ := s.Categories(CategoryQuery{Offset: 2, Limit: 2})
+		assert.Equal(t, 3, page.Paging.Total)
+		assert.Len(t, page.Results, 1)
+
+		page, _ = s.Categories(CategoryQuery{Site: "MLB"})
+		assert.Empty(t, page.Results)
+	}
+
+	t.Log("Given an unknown sort, Categories returns error.", checkMark)
+	{
+		_, err := s.Categories(CategoryQuery{Sort: "price"})
+		assert.NotNil(t, err)
+	}
+}
+
+func TestSuggester_Category(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := newCategoriesTestSuggester(config)
+
+	t.Log("Given a category of the model, it returns its path, children and statistics.", checkMark)
+	{
+		detail, err := s.Category(CategoryIdAttributionRoot)
+		assert.Nil(t, err)
+		assert.Equal(t, "Electrónica", detail.Name)
+		assert.Equal(t, []CategoryRef{{Id: CategoryIdAttributionRoot, Name: "Electrónica", Trained: true}}, detail.Path)
+		assert.Equal(t, []CategoryRef{
+			{Id: CategoryIdAttributionParent, Name: "Celulares", Trained: true},
+			{Id: CategoryIdAttributionSibling, Trained: true},
+		}, detail.Children)
+		assert.Equal(t, 3, detail.Stats.SampleSize)
+		assert.Equal(t, 90.0, detail.Stats.Sum)
+	}
+
+	t.Log("Given an unknown category, Category returns error.", checkMark)
+	{
+		_, err := s.Category(CategoryIdAttributionGrandchild)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestSuggesterCtrl_ListCategories(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	ctrl := SuggesterCtrl{Suggester: newCategoriesTestSuggester(config)}
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+
+	router.GET("/categories", ctrl.ListCategories)
+	router.GET("/categories/:categoryId", ctrl.CategoryById)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Log("Given a query, /categories returns a page of categories.", checkMark)
+	{
+		resp := get("/categories?sort=-sample_size&limit=1")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"paging":{"total":3,"offset":0,"limit":1}`)
+		assert.Contains(t, resp.Body.String(), fmt.Sprintf(`"id":"%s"`, CategoryIdAttributionRoot))
+	}
+
+	t.Log("Given an invalid limit, /categories returns 400.", checkMark)
+	assert.Equal(t, http.StatusBadRequest, get("/categories?limit=all").Code)
+
+	t.Log("Given a category, /categories/{categoryId} returns its detail.", checkMark)
+	{
+		resp := get("/categories/" + CategoryIdAttributionParent)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"name":"Celulares"`)
+		assert.Equal(t, http.StatusNotFound, get("/categories/"+CategoryIdAttributionGrandchild).Code)
+	}
+}
+
+func TestValidateCategoryId(t *testing.T) {
+	t.Log("Given a category id of the site, it is valid.", checkMark)
+	assert.Nil(t, ValidateCategoryId(meli.SITE_MLA, CategoryIdTest))
+
+	t.Log("Given a malformed category id or one of another site, it is an invalid category id.", checkMark)
+	for _, categoryId := range []string{"", "1051", "MLA", "mla1051", "MLA1051 ", "MLA10x51", "MLAB1051", "MLB1051"} {
+		err := ValidateCategoryId(meli.SITE_MLA, categoryId)
+		assert.Equal(t, ERR_INVALID_CATEGORY_ID, ErrorCode(err), categoryId)
+	}
+}
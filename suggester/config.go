@@ -0,0 +1,322 @@
+package suggester
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	CONFIG                           string = "config"
+	CONFIG_SHOW                      string = "show"
+	DEFAULT_SAMPLE_PAGE_SIZE                = 50
+	DEFAULT_SAMPLE_CONFIDENCE               = 2.58
+	DEFAULT_SAMPLE_PRECISION                = 0.05
+	DEFAULT_RETRY_MAX                       = 20
+	DEFAULT_RETRY_DELAY_MS                  = 1000
+	DEFAULT_SERVER_ADDRESS                  = ":8080"
+	DEFAULT_SERVER_READ_TIMEOUT_MS          = 5000
+	DEFAULT_SERVER_WRITE_TIMEOUT_MS         = 10000
+	DEFAULT_SERVER_IDLE_TIMEOUT_MS          = 60000
+	DEFAULT_SERVER_SHUTDOWN_GRACE_MS        = 10000
+	DEFAULT_SERVER_CACHE_CONTROL            = "public, max-age=300"
+	DEFAULT_SERVER_RATE_BURST               = 20
+)
+
+// Config is the configuration of the suggester. It is loaded from a YAML or
+// TOML file, then environment variables and then command line flags.
+type Config struct {
+	DataSetPath     string         `yaml:"data_set_path" toml:"data_set_path"`
+	DataTrainedPath string         `yaml:"data_trained_path" toml:"data_trained_path"`
+	Storage         string         `yaml:"storage" toml:"storage"`
+	BoltFile        string         `yaml:"bolt_file" toml:"bolt_file"`
+	PriceIndexFile  string         `yaml:"price_index_file" toml:"price_index_file"`
+	DataSetFormat   string         `yaml:"data_set_format" toml:"data_set_format"`
+	Site            string         `yaml:"site" toml:"site"`
+	Endpoint        string         `yaml:"endpoint" toml:"endpoint"`
+	Attribution     string         `yaml:"attribution" toml:"attribution"`
+	Sampling        SamplingConfig `yaml:"sampling" toml:"sampling"`
+	Retry           RetryConfig    `yaml:"retry" toml:"retry"`
+	Server          ServerConfig   `yaml:"server" toml:"server"`
+}
+
+// SamplingConfig are the parameters of the systematic random sampling of a category.
+type SamplingConfig struct {
+	// PageSize is the number of items fetched at each sampled offset.
+	PageSize int `yaml:"page_size" toml:"page_size" json:"page_size"`
+	// Confidence is the Z value of the confidence level of the sample size, 2.58 for 99%.
+	Confidence float64 `yaml:"confidence" toml:"confidence" json:"confidence"`
+	// Precision is the acceptable error of the sample size.
+	Precision float64 `yaml:"precision" toml:"precision" json:"precision"`
+}
+
+// RetryConfig is the retry policy of the requests to the Meli API.
+type RetryConfig struct {
+	MaxRetries int `yaml:"max_retries" toml:"max_retries"`
+	DelayMs    int `yaml:"delay_ms" toml:"delay_ms"`
+}
+
+// ServerConfig is the configuration of the http service. TLS is served when
+// both the cert and key files are set. Zero read, write and idle timeouts
+// disable them.
+type ServerConfig struct {
+	Address        string `yaml:"address" toml:"address"`
+	CertFile       string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile        string `yaml:"key_file" toml:"key_file"`
+	ReadTimeoutMs  int    `yaml:"read_timeout_ms" toml:"read_timeout_ms"`
+	WriteTimeoutMs int    `yaml:"write_timeout_ms" toml:"write_timeout_ms"`
+	IdleTimeoutMs  int    `yaml:"idle_timeout_ms" toml:"idle_timeout_ms"`
+	// ShutdownGraceMs is how long in-flight requests are waited for on shutdown.
+	ShutdownGraceMs int `yaml:"shutdown_grace_ms" toml:"shutdown_grace_ms"`
+	// CacheControl is the Cache-Control header of the responses derived from
+	// the model, none if empty.
+	CacheControl string `yaml:"cache_control" toml:"cache_control"`
+	// ApiKeysFile is the YAML file of the API keys clients authenticate with.
+	// The API is open when it is empty.
+	ApiKeysFile string `yaml:"api_keys_file" toml:"api_keys_file"`
+	// RateLimit is the requests per second each API key, or client IP when the
+	// API is open, can make, up to RateBurst at once. Zero disables it.
+	RateLimit float64 `yaml:"rate_limit" toml:"rate_limit"`
+	RateBurst int     `yaml:"rate_burst" toml:"rate_burst"`
+}
+
+// configKey binds a setting to its flag name and environment variable.
+type configKey struct {
+	flag  string
+	env   string
+	usage string
+	set   func(config *Config, value string) error
+}
+
+var configKeys = []configKey{
+	{"data-set-path", "DATA_SET_PATH", "Folder of the data set.", setString(func(c *Config) *string { return &c.DataSetPath })},
+	{"data-trained-path", "DATA_TRAINED_PATH", "Folder of the data trained.", setString(func(c *Config) *string { return &c.DataTrainedPath })},
+	{"storage", "STORAGE", "Storage of data set and data trained: file or bolt.", setString(func(c *Config) *string { return &c.Storage })},
+	{"bolt-file", "BOLT_FILE", "Database file of the bolt storage.", setString(func(c *Config) *string { return &c.BoltFile })},
+	{"price-index-file", "PRICE_INDEX_FILE", "CSV file of the price index.", setString(func(c *Config) *string { return &c.PriceIndexFile })},
+	{"data-set-format", "DATA_SET_FORMAT", "Format of fetched data sets: json or jsonl.gz.", setString(func(c *Config) *string { return &c.DataSetFormat })},
+	{"site", "SITE", "Meli site to fetch.", setString(func(c *Config) *string { return &c.Site })},
+	{"endpoint", "MELI_ENDPOINT", "Meli API endpoint.", setString(func(c *Config) *string { return &c.Endpoint })},
+	{"attribution", "ATTRIBUTION", "Categories an item is trained into: folder, item or tree.", setString(func(c *Config) *string { return &c.Attribution })},
+	{"sample-page-size", "SAMPLE_PAGE_SIZE", "Items fetched at each sampled offset.", setInt(func(c *Config) *int { return &c.Sampling.PageSize })},
+	{"sample-confidence", "SAMPLE_CONFIDENCE", "Z value of the confidence level of the sample size.", setFloat(func(c *Config) *float64 { return &c.Sampling.Confidence })},
+	{"sample-precision", "SAMPLE_PRECISION", "Acceptable error of the sample size.", setFloat(func(c *Config) *float64 { return &c.Sampling.Precision })},
+	{"retry-max", "RETRY_MAX", "Retries of a failed Meli API request.", setInt(func(c *Config) *int { return &c.Retry.MaxRetries })},
+	{"retry-delay-ms", "RETRY_DELAY_MS", "Milliseconds between retries.", setInt(func(c *Config) *int { return &c.Retry.DelayMs })},
+	{"server-address", "SERVER_ADDRESS", "Address the http service listens on.", setString(func(c *Config) *string { return &c.Server.Address })},
+	{"server-cert-file", "SERVER_CERT_FILE", "TLS certificate file of the http service.", setString(func(c *Config) *string { return &c.Server.CertFile })},
+	{"server-key-file", "SERVER_KEY_FILE", "TLS key file of the http service.", setString(func(c *Config) *string { return &c.Server.KeyFile })},
+	{"server-read-timeout-ms", "SERVER_READ_TIMEOUT_MS", "Milliseconds to read a request.", setInt(func(c *Config) *int { return &c.Server.ReadTimeoutMs })},
+	{"server-write-timeout-ms", "SERVER_WRITE_TIMEOUT_MS", "Milliseconds to write a response.", setInt(func(c *Config) *int { return &c.Server.WriteTimeoutMs })},
+	{"server-idle-timeout-ms", "SERVER_IDLE_TIMEOUT_MS", "Milliseconds an idle keep-alive connection is kept.", setInt(func(c *Config) *int { return &c.Server.IdleTimeoutMs })},
+	{"server-shutdown-grace-ms", "SERVER_SHUTDOWN_GRACE_MS", "Milliseconds in-flight requests are waited for on shutdown.", setInt(func(c *Config) *int { return &c.Server.ShutdownGraceMs })},
+	{"server-cache-control", "SERVER_CACHE_CONTROL", "Cache-Control header of the responses derived from the model.", setString(func(c *Config) *string { return &c.Server.CacheControl })},
+	{"server-api-keys-file", "SERVER_API_KEYS_FILE", "YAML file of the API keys, the API is open if empty.", setString(func(c *Config) *string { return &c.Server.ApiKeysFile })},
+	{"server-rate-limit", "SERVER_RATE_LIMIT", "Requests per second per API key or client IP, 0 disables it.", setFloat(func(c *Config) *float64 { return &c.Server.RateLimit })},
+	{"server-rate-burst", "SERVER_RATE_BURST", "Requests per API key or client IP allowed at once.", setInt(func(c *Config) *int { return &c.Server.RateBurst })},
+}
+
+// DefaultConfig returns the configuration used when nothing is set.
+func DefaultConfig() Config {
+	return Config{
+		DataSetPath:     DATA_SET_PATH,
+		DataTrainedPath: DATA_TRAINED_PATH,
+		Storage:         STORAGE_FILE,
+		BoltFile:        BOLT_FILE_PATH,
+		PriceIndexFile:  PRICE_INDEX_FILE_PATH,
+		DataSetFormat:   DATA_SET_FORMAT_JSON,
+		Site:            meli.SITE_MLA,
+		Endpoint:        meli.MELI_API_ENDPOINT,
+		Attribution:     ATTRIBUTION_FOLDER,
+		Sampling: SamplingConfig{
+			PageSize:   DEFAULT_SAMPLE_PAGE_SIZE,
+			Confidence: DEFAULT_SAMPLE_CONFIDENCE,
+			Precision:  DEFAULT_SAMPLE_PRECISION,
+		},
+		Retry: RetryConfig{
+			MaxRetries: DEFAULT_RETRY_MAX,
+			DelayMs:    DEFAULT_RETRY_DELAY_MS,
+		},
+		Server: ServerConfig{
+			Address:         DEFAULT_SERVER_ADDRESS,
+			ReadTimeoutMs:   DEFAULT_SERVER_READ_TIMEOUT_MS,
+			WriteTimeoutMs:  DEFAULT_SERVER_WRITE_TIMEOUT_MS,
+			IdleTimeoutMs:   DEFAULT_SERVER_IDLE_TIMEOUT_MS,
+			ShutdownGraceMs: DEFAULT_SERVER_SHUTDOWN_GRACE_MS,
+			CacheControl:    DEFAULT_SERVER_CACHE_CONTROL,
+			RateBurst:       DEFAULT_SERVER_RATE_BURST,
+		},
+	}
+}
+
+// LoadConfig returns the default configuration overridden by the file at path,
+// if not empty, and then by environment variables.
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+
+	if path != "" {
+		if err := config.readFile(path); err != nil {
+			return config, err
+		}
+	}
+
+	for _, key := range configKeys {
+		if value, ok := os.LookupEnv(key.env); ok && value != "" {
+			if err := key.set(&config, value); err != nil {
+				return config, errors.New(fmt.Sprintf("Env var: %s %s", key.env, err))
+			}
+		}
+	}
+
+	return config, config.Validate()
+}
+
+// readFile decodes a YAML or TOML file, by its extension, over the configuration.
+func (c *Config) readFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		_, err = toml.Decode(string(data), c)
+	default:
+		err = errors.New("config file must be .yaml, .yml or .toml")
+	}
+
+	if err != nil {
+		return errors.New(fmt.Sprintf("Config file: %s %s", path, err))
+	}
+
+	return nil
+}
+
+// RegisterFlags defines a flag per setting in flags. Flags not given keep the loaded value.
+func RegisterFlags(flags *flag.FlagSet) {
+	for _, key := range configKeys {
+		flags.String(key.flag, "", fmt.Sprintf("%s (env %s)", key.usage, key.env))
+	}
+}
+
+// ApplyFlags overrides the configuration with the flags given in flags.
+func (c *Config) ApplyFlags(flags *flag.FlagSet) error {
+	var err error
+
+	flags.Visit(func(f *flag.Flag) {
+		for _, key := range configKeys {
+			if key.flag == f.Name && err == nil {
+				if setErr := key.set(c, f.Value.String()); setErr != nil {
+					err = errors.New(fmt.Sprintf("Flag: -%s %s", key.flag, setErr))
+				}
+			}
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.Validate()
+}
+
+// Validate checks the settings that would make fetching or serving fail later.
+func (c Config) Validate() error {
+	switch {
+	case c.DataSetPath == "" || c.DataTrainedPath == "":
+		return errors.New("Config: data set and data trained paths mustn't be empty.")
+	case c.Storage != STORAGE_FILE && c.Storage != STORAGE_BOLT:
+		return errors.New(fmt.Sprintf("Config: storage: %s is not supported.", c.Storage))
+	case c.DataSetFormat != DATA_SET_FORMAT_JSON && c.DataSetFormat != DATA_SET_FORMAT_JSONL_GZIP:
+		return errors.New(fmt.Sprintf("Config: data set format: %s is not supported.", c.DataSetFormat))
+	case c.Attribution != ATTRIBUTION_FOLDER && c.Attribution != ATTRIBUTION_ITEM && c.Attribution != ATTRIBUTION_TREE:
+		return errors.New(fmt.Sprintf("Config: attribution: %s is not supported.", c.Attribution))
+	case c.Sampling.PageSize <= 0:
+		return errors.New("Config: sampling page size must be greater than 0.")
+	case c.Sampling.Confidence <= 0 || c.Sampling.Precision <= 0 || c.Sampling.Precision >= 1:
+		return errors.New("Config: sampling confidence must be greater than 0 and precision between 0 and 1.")
+	case c.Retry.MaxRetries < 0 || c.Retry.DelayMs < 0:
+		return errors.New("Config: retry max and delay mustn't be negative.")
+	case (c.Server.CertFile == "") != (c.Server.KeyFile == ""):
+		return errors.New("Config: server cert and key files must be set together.")
+	case c.Server.ReadTimeoutMs < 0 || c.Server.WriteTimeoutMs < 0 || c.Server.IdleTimeoutMs < 0 || c.Server.ShutdownGraceMs < 0:
+		return errors.New("Config: server timeouts and shutdown grace mustn't be negative.")
+	case c.Server.RateLimit < 0 || (c.Server.RateLimit > 0 && c.Server.RateBurst < 1):
+		return errors.New("Config: server rate limit mustn't be negative and its burst must be at least 1.")
+	}
+	return nil
+}
+
+// String returns the configuration as YAML.
+func (c Config) String() string {
+	out, _ := yaml.Marshal(c)
+	return string(out)
+}
+
+// RetryDelay returns the time to wait between retries.
+func (c Config) RetryDelay() time.Duration {
+	return time.Duration(c.Retry.DelayMs) * time.Millisecond
+}
+
+// ReadTimeout returns the time to read a request.
+func (c ServerConfig) ReadTimeout() time.Duration {
+	return time.Duration(c.ReadTimeoutMs) * time.Millisecond
+}
+
+// WriteTimeout returns the time to write a response.
+func (c ServerConfig) WriteTimeout() time.Duration {
+	return time.Duration(c.WriteTimeoutMs) * time.Millisecond
+}
+
+// IdleTimeout returns the time an idle keep-alive connection is kept.
+func (c ServerConfig) IdleTimeout() time.Duration {
+	return time.Duration(c.IdleTimeoutMs) * time.Millisecond
+}
+
+// ShutdownGrace returns the time in-flight requests are waited for on shutdown.
+func (c ServerConfig) ShutdownGrace() time.Duration {
+	return time.Duration(c.ShutdownGraceMs) * time.Millisecond
+}
+
+// TLS returns whether the http service is served with TLS.
+func (c ServerConfig) TLS() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+func setString(field func(c *Config) *string) func(config *Config, value string) error {
+	return func(config *Config, value string) error {
+		*field(config) = value
+		return nil
+	}
+}
+
+func setInt(field func(c *Config) *int) func(config *Config, value string) error {
+	return func(config *Config, value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err == nil {
+			*field(config) = parsed
+		}
+		return err
+	}
+}
+
+func setFloat(field func(c *Config) *float64) func(config *Config, value string) error {
+	return func(config *Config, value string) error {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			*field(config) = parsed
+		}
+		return err
+	}
+}
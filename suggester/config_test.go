@@ -0,0 +1,94 @@
+package suggester
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	folder, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(folder)
+
+	yamlFile := filepath.Join(folder, "suggester.yaml")
+	ioutil.WriteFile(yamlFile, []byte("data_set_path: /data/dataset\nsampling:\n  page_size: 20\nserver:\n  address: \":9090\"\n"), 0644)
+
+	config, err := LoadConfig(yamlFile)
+
+	assert.Nil(t, err)
+	t.Log("Given a YAML file, LoadConfig overrides the defaults with it.", checkMark)
+	assert.Equal(t, "/data/dataset", config.DataSetPath)
+	assert.Equal(t, 20, config.Sampling.PageSize)
+	assert.Equal(t, ":9090", config.Server.Address)
+	assert.Equal(t, DATA_TRAINED_PATH, config.DataTrainedPath)
+
+	tomlFile := filepath.Join(folder, "suggester.toml")
+	ioutil.WriteFile(tomlFile, []byte("site = \"MLB\"\n[retry]\nmax_retries = 3\n"), 0644)
+
+	os.Setenv("RETRY_DELAY_MS", "10")
+	defer os.Unsetenv("RETRY_DELAY_MS")
+
+	config, err = LoadConfig(tomlFile)
+
+	assert.Nil(t, err)
+	t.Log("Given a TOML file and an env var, LoadConfig applies both.", checkMark)
+	assert.Equal(t, "MLB", config.Site)
+	assert.Equal(t, 3, config.Retry.MaxRetries)
+	assert.Equal(t, 10, config.Retry.DelayMs)
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(flags)
+	flags.Parse([]string{"-site", "MLM", "-sample-precision", "0.1"})
+
+	err = config.ApplyFlags(flags)
+
+	assert.Nil(t, err)
+	t.Log("Given flags, ApplyFlags overrides only the flags given.", checkMark)
+	assert.Equal(t, "MLM", config.Site)
+	assert.Equal(t, 0.1, config.Sampling.Precision)
+	assert.Equal(t, 3, config.Retry.MaxRetries)
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	os.Setenv("STORAGE", "s3")
+	defer os.Unsetenv("STORAGE")
+
+	_, err := LoadConfig("")
+
+	t.Log("Given an unsupported storage, LoadConfig returns error.", checkMark)
+	assert.NotNil(t, err)
+
+	_, err = LoadConfig("suggester.ini")
+
+	t.Log("Given a file that can not be read, LoadConfig returns error.", checkMark)
+	assert.NotNil(t, err)
+}
+
+func TestConfig_ValidateServer(t *testing.T) {
+	config := DefaultConfig()
+	config.Server.CertFile = "server.crt"
+
+	t.Log("Given a cert file without key file, Validate returns error.", checkMark)
+	assert.NotNil(t, config.Validate())
+
+	config.Server.KeyFile = "server.key"
+
+	t.Log("Given a cert and key file, the server is served with TLS.", checkMark)
+	assert.Nil(t, config.Validate())
+	assert.True(t, config.Server.TLS())
+
+	config.Server.ShutdownGraceMs = -1
+
+	t.Log("Given a negative shutdown grace, Validate returns error.", checkMark)
+	assert.NotNil(t, config.Validate())
+
+	config.Server.ShutdownGraceMs = DEFAULT_SERVER_SHUTDOWN_GRACE_MS
+	config.Server.RateLimit = 5
+	config.Server.RateBurst = 0
+
+	t.Log("Given a rate limit without burst, Validate returns error.", checkMark)
+	assert.NotNil(t, config.Validate())
+}
@@ -1,18 +1,22 @@
 package suggester
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type SuggesterCtrl struct {
 	Suggester *Suggester
 }
 
-func NewSuggesterCtrl() *SuggesterCtrl {
+func NewSuggesterCtrl(suggester *Suggester) *SuggesterCtrl {
 	s := &SuggesterCtrl{
-		Suggester: NewSuggester(),
+		Suggester: suggester,
 	}
 
 	return s
@@ -22,26 +26,296 @@ func (s *SuggesterCtrl) SuggestPriceByCategory(c *gin.Context) {
 	categoryId := c.Param("categoryId")
 
 	// Validate param
-	if len(categoryId) == 0 {
-		c.JSON(http.StatusBadRequest, ApiErr{Message: "CategoryId param is empty."})
+	if err := s.Suggester.ValidateCategoryId(categoryId); err != nil {
+		abortWithError(c, err)
 		return
 	}
 
-	// Suggest prices for category
-	result, err := s.Suggester.Suggest(categoryId)
+	model := s.Suggester.currentModel()
+	adjust := c.Query("adjust")
+
+	// Suggest prices for category, optionally adjusted to today with ?adjust=trend|index
+	result, err := s.Suggester.suggestAdjusted(model, categoryId, adjust)
+
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// Adjusted prices change with the clock, only the raw ones can be cached until the model changes.
+	if adjust == ADJUSTMENT_NONE && s.notModified(c, model.dataTrained.Version(), model.dataTrained.TrainedAt(), categoryId) {
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *SuggesterCtrl) PriceHistoryByCategory(c *gin.Context) {
+	categoryId := c.Param("categoryId")
+
+	// Validate param
+	if err := s.Suggester.ValidateCategoryId(categoryId); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	model := s.Suggester.currentModel()
+
+	// Price series and trend for category
+	result, err := model.trend(categoryId)
+
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	if s.notModified(c, model.priceHistoryVersion, model.priceHistoryAt, categoryId+"-history") {
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListCategories returns a page of the categories with suggestions, filtered
+// by ?site=, ?parent= and ?name= and sorted by ?sort=id|sample_size|-sample_size.
+func (s *SuggesterCtrl) ListCategories(c *gin.Context) {
+	query := CategoryQuery{
+		Site:   c.Query("site"),
+		Parent: c.Query("parent"),
+		Name:   c.Query("name"),
+		Sort:   c.Query("sort"),
+	}
+
+	var err error
+
+	if offset := c.Query("offset"); offset != "" {
+		if query.Offset, err = strconv.Atoi(offset); err != nil {
+			abortWithError(c, newSuggesterError(ERR_INVALID_REQUEST, "Offset param must be a number."))
+			return
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			abortWithError(c, newSuggesterError(ERR_INVALID_REQUEST, "Limit param must be a number."))
+			return
+		}
+	}
+
+	result, err := s.Suggester.Categories(query)
+
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CategoryById returns a category with its path, children and trained statistics.
+func (s *SuggesterCtrl) CategoryById(c *gin.Context) {
+	categoryId := c.Param("categoryId")
+
+	if err := s.Suggester.ValidateCategoryId(categoryId); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	result, err := s.Suggester.Category(categoryId)
 
 	if err != nil {
-		c.JSON(http.StatusNotFound, ApiErr{Message: err.Error()})
+		abortWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, result)
 }
 
+// Health reports the process is alive.
+func (s *SuggesterCtrl) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready reports whether a non-empty model is loaded, so prices can be suggested.
+func (s *SuggesterCtrl) Ready(c *gin.Context) {
+	dataTrained := s.Suggester.GetInMemoryDataTrained()
+
+	if dataTrained == nil || dataTrained.Len() == 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "message": "Data trained not loaded or empty."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "version": dataTrained.Version()})
+}
+
+// ModelInfo describes the model prices are suggested with.
+func (s *SuggesterCtrl) ModelInfo(c *gin.Context) {
+	result, err := s.Suggester.ModelInfo()
+
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// JobsCtrl serves the admin endpoints of the fetch and train jobs.
+type JobsCtrl struct {
+	Jobs *Jobs
+}
+
+func NewJobsCtrl(jobs *Jobs) *JobsCtrl {
+	return &JobsCtrl{Jobs: jobs}
+}
+
+// StartFetch starts fetching the categories of the body, every category of the site without body.
+func (j *JobsCtrl) StartFetch(c *gin.Context) {
+	var request FetchJobRequest
+
+	if !bindJobRequest(c, &request) {
+		return
+	}
+
+	job, err := j.Jobs.StartFetch(request)
+	j.started(c, job, err)
+}
+
+// StartTrain starts training with the options of the body, every category without body.
+func (j *JobsCtrl) StartTrain(c *gin.Context) {
+	var request TrainJobRequest
+
+	if !bindJobRequest(c, &request) {
+		return
+	}
+
+	job, err := j.Jobs.StartTrain(request)
+	j.started(c, job, err)
+}
+
+// JobById returns the status, progress and errors of a job.
+func (j *JobsCtrl) JobById(c *gin.Context) {
+	job, err := j.Jobs.Get(c.Param("jobId"))
+
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// JobEvents streams the progress events of a job as Server-Sent Events. A job
+// event with the job is sent first and last, progress events in between. The
+// write timeout of the server applies to each event, not to the whole stream.
+func (j *JobsCtrl) JobEvents(c *gin.Context) {
+	id := c.Param("jobId")
+
+	events, cancel, err := j.Jobs.Events(id)
+
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	defer cancel()
+
+	writeTimeout := j.Jobs.suggester.config.Server.WriteTimeout()
+	controller := http.NewResponseController(serverResponseWriter(c))
+
+	// Without a write deadline to extend the stream ends at the write timeout
+	extendWriteDeadline := func() {
+		if writeTimeout <= 0 {
+			return
+		}
+		if err := controller.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+			j.Jobs.suggester.logger.Warning(fmt.Sprintf("[JobEvents] Job: %s events end at the write timeout: %s", id, err))
+			writeTimeout = 0
+		}
+	}
+
+	job, _ := j.Jobs.Get(id)
+	extendWriteDeadline()
+	c.SSEvent("job", job)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			extendWriteDeadline()
+			if !ok {
+				job, _ := j.Jobs.Get(id)
+				c.SSEvent("job", job)
+				return false
+			}
+			c.SSEvent("progress", event)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func (j *JobsCtrl) started(c *gin.Context, job Job, err error) {
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Location", "/admin/jobs/"+job.Id)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// bindJobRequest decodes the json body of a job request, if any.
+func bindJobRequest(c *gin.Context, request interface{}) bool {
+	if err := json.NewDecoder(c.Request.Body).Decode(request); err != nil && err != io.EOF {
+		abortWithError(c, newSuggesterError(ERR_INVALID_REQUEST, fmt.Sprintf("Job request body: %s", err)))
+		return false
+	}
+
+	return true
+}
+
+// abortWithError responds with the status of the code of err and an ApiErr.
+func abortWithError(c *gin.Context, err error) {
+	code := ErrorCode(err)
+
+	status, ok := errorStatus[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	c.AbortWithStatusJSON(status, ApiErr{
+		Code:             code,
+		Message:          err.Error(),
+		RequestId:        c.GetString(REQUEST_ID_KEY),
+		DocumentationUrl: API_ERRORS_DOCUMENTATION_URL + code,
+	})
+}
+
+// errorStatus is the http status of each error code.
+var errorStatus = map[string]int{
+	ERR_CATEGORY_NOT_FOUND:  http.StatusNotFound,
+	ERR_MODEL_NOT_LOADED:    http.StatusServiceUnavailable,
+	ERR_INVALID_CATEGORY_ID: http.StatusBadRequest,
+	ERR_INSUFFICIENT_DATA:   http.StatusUnprocessableEntity,
+	ERR_INVALID_REQUEST:     http.StatusBadRequest,
+	ERR_UNAUTHORIZED:        http.StatusUnauthorized,
+	ERR_FORBIDDEN:           http.StatusForbidden,
+	ERR_RATE_LIMITED:        http.StatusTooManyRequests,
+	ERR_JOB_NOT_FOUND:       http.StatusNotFound,
+	ERR_JOB_CONFLICT:        http.StatusConflict,
+	ERR_INTERNAL:            http.StatusInternalServerError,
+}
+
+// ApiErr is the body of every error response of the API.
 type ApiErr struct {
-	Message string
+	Code             string `json:"code"`
+	Message          string `json:"message"`
+	RequestId        string `json:"request_id,omitempty"`
+	DocumentationUrl string `json:"documentation_url"`
 }
 
 func (e ApiErr) Error() string {
-	return fmt.Sprintf("Error: %s", e.Message)
+	return fmt.Sprintf("Error: %s %s", e.Code, e.Message)
 }
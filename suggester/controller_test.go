@@ -1,12 +1,15 @@
 package suggester
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 const CategoryIdTest string = "MLA1051"
@@ -24,11 +27,16 @@ func init() {
 }
 
 func TestNewSuggesterCtrl(t *testing.T) {
-	suggesterCtrl := NewSuggesterCtrl()
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	suggesterCtrl := NewSuggesterCtrl(NewSuggester(config))
 	assert.NotNil(t, suggesterCtrl)
 }
 
 func TestSuggesterCtrl_SuggestPriceByCategory(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
 
 	expectedResult := `{"max":100,"suggested":90,"min":60}`
 
@@ -36,7 +44,7 @@ func TestSuggesterCtrl_SuggestPriceByCategory(t *testing.T) {
 
 	{
 		// Set dataTrained in Suggester
-		s := NewSuggester()
+		s := NewSuggester(config)
 		s.SetInMemoryDataTrained(dataTrainedTest)
 
 		// Make a new Suggester Controller or handlers
@@ -63,12 +71,248 @@ func TestSuggesterCtrl_SuggestPriceByCategory(t *testing.T) {
 	}
 }
 
+func TestSuggesterCtrl_PriceHistoryByCategory(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	t.Log("Given a categoryId: ", CategoryIdTest, " /categories/{categoryId}/prices/history returns the price series. ")
+
+	{
+		s := NewSuggester(config)
+		s.SetInMemoryPriceHistory(map[string][]PricePoint{
+			CategoryIdTest: {{Median: 90, Total: 2}},
+		})
+
+		ctrl := SuggesterCtrl{Suggester: s}
+
+		gin.SetMode(gin.TestMode)
+
+		router := gin.New()
+
+		router.GET("/categories/:categoryId/prices/history", ctrl.PriceHistoryByCategory)
+
+		url := fmt.Sprintf("/categories/%s/prices/history", CategoryIdTest)
+
+		req, _ := http.NewRequest("GET", url, nil)
+
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"category_id":"MLA1051"`)
+		assert.Contains(t, resp.Body.String(), `"median":90`)
+	}
+}
+
+func TestSuggesterCtrl_Ready(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := NewSuggester(config)
+	ctrl := SuggesterCtrl{Suggester: s}
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+
+	router.GET("/healthz", ctrl.Health)
+	router.GET("/readyz", ctrl.Ready)
+	router.GET("/model", ctrl.ModelInfo)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Log("Given no model loaded, /healthz returns 200 and /readyz and /model return 503.", checkMark)
+	{
+		assert.Equal(t, http.StatusOK, get("/healthz").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, get("/readyz").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, get("/model").Code)
+	}
+
+	s.SetInMemoryDataTrained(map[string]CategoryPriceTrained{})
+
+	t.Log("Given an empty model, /readyz returns 503.", checkMark)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz").Code)
+
+	s.SetInMemoryDataTrained(dataTrainedTest)
+
+	t.Log("Given a model, /readyz returns 200 and /model describes it.", checkMark)
+	{
+		assert.Equal(t, http.StatusOK, get("/readyz").Code)
+
+		resp := get("/model")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"categories":1`)
+		assert.Contains(t, resp.Body.String(), `"sites":["MLA"]`)
+	}
+}
+
+func TestSuggesterCtrl_Errors(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := NewSuggester(config)
+	ctrl := SuggesterCtrl{Suggester: s}
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestId())
+
+	router.GET("/categories/:categoryId/prices", ctrl.SuggestPriceByCategory)
+
+	get := func(url string) (*httptest.ResponseRecorder, ApiErr) {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set(REQUEST_ID_HEADER, "test-request")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var apiErr ApiErr
+		json.Unmarshal(resp.Body.Bytes(), &apiErr)
+		return resp, apiErr
+	}
+
+	t.Log("Given no model loaded, it returns 503 with the model_not_loaded code.", checkMark)
+	{
+		resp, apiErr := get("/categories/" + CategoryIdTest + "/prices")
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		assert.Equal(t, ERR_MODEL_NOT_LOADED, apiErr.Code)
+		assert.Equal(t, "test-request", apiErr.RequestId)
+		assert.Equal(t, "test-request", resp.Header().Get(REQUEST_ID_HEADER))
+		assert.Equal(t, API_ERRORS_DOCUMENTATION_URL+ERR_MODEL_NOT_LOADED, apiErr.DocumentationUrl)
+	}
+
+	s.SetInMemoryDataTrained(dataTrainedTest)
+
+	t.Log("Given a malformed category id, it returns 400 with the invalid_category_id code.", checkMark)
+	{
+		resp, apiErr := get("/categories/MLB1051/prices")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, ERR_INVALID_CATEGORY_ID, apiErr.Code)
+		assert.Equal(t, "Category id: MLB1051 is not of site MLA.", apiErr.Message)
+	}
+
+	t.Log("Given an unknown category, it returns 404 with the category_not_found code.", checkMark)
+	{
+		resp, apiErr := get("/categories/MLA0/prices")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Equal(t, ERR_CATEGORY_NOT_FOUND, apiErr.Code)
+		assert.Equal(t, "Category: MLA0 not found.", apiErr.Message)
+	}
+
+	t.Log("Given an adjustment without snapshot date, it returns 422 with the insufficient_data code.", checkMark)
+	{
+		resp, apiErr := get("/categories/" + CategoryIdTest + "/prices?adjust=trend")
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+		assert.Equal(t, ERR_INSUFFICIENT_DATA, apiErr.Code)
+	}
+
+	t.Log("Given an unknown adjustment, it returns 400 with the invalid_request code.", checkMark)
+	{
+		s.SetInMemoryDataTrained(map[string]CategoryPriceTrained{
+			CategoryIdTest: {Max: 100, Suggested: 90, Min: 60, SnapshotDate: time.Now()},
+		})
+		resp, apiErr := get("/categories/" + CategoryIdTest + "/prices?adjust=magic")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, ERR_INVALID_REQUEST, apiErr.Code)
+	}
+}
+
+func TestSuggesterCtrl_ConditionalRequests(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	trainedAt := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	s := NewSuggester(config, WithClock(func() time.Time { return trainedAt }))
+	s.SetInMemoryDataTrained(dataTrainedTest)
+	ctrl := SuggesterCtrl{Suggester: s}
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/categories/:categoryId/prices", ctrl.SuggestPriceByCategory)
+
+	url := "/categories/" + CategoryIdTest + "/prices"
+	get := func(header string, value string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := get("", "")
+	etag := resp.Header().Get("ETag")
+
+	t.Log("Given a suggestion, it has the model version and category ETag, training Last-Modified and Cache-Control.", checkMark)
+	{
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "\""+s.GetInMemoryDataTrained().Version()+"-"+CategoryIdTest+"\"", etag)
+		assert.Equal(t, "Fri, 01 Jun 2018 10:00:00 GMT", resp.Header().Get("Last-Modified"))
+		assert.Equal(t, DEFAULT_SERVER_CACHE_CONTROL, resp.Header().Get("Cache-Control"))
+	}
+
+	t.Log("Given a valid copy, it returns 304 without body.", checkMark)
+	{
+		resp := get("If-None-Match", "\"other\", "+etag)
+		assert.Equal(t, http.StatusNotModified, resp.Code)
+		assert.Empty(t, resp.Body.String())
+
+		assert.Equal(t, http.StatusNotModified, get("If-Modified-Since", "Fri, 01 Jun 2018 10:00:00 GMT").Code)
+	}
+
+	t.Log("Given a stale copy, it returns 200.", checkMark)
+	{
+		assert.Equal(t, http.StatusOK, get("If-None-Match", "\"other\"").Code)
+		assert.Equal(t, http.StatusOK, get("If-Modified-Since", "Thu, 31 May 2018 10:00:00 GMT").Code)
+	}
+
+	t.Log("Given a new model, the ETag changes.", checkMark)
+	{
+		s.SetInMemoryDataTrained(map[string]CategoryPriceTrained{CategoryIdTest: {Max: 1, Suggested: 1, Min: 1}})
+		assert.Equal(t, http.StatusOK, get("If-None-Match", etag).Code)
+	}
+
+	router.GET("/categories/:categoryId/prices/history", ctrl.PriceHistoryByCategory)
+	url = "/categories/" + CategoryIdTest + "/prices/history"
+
+	s.SetInMemoryPriceHistory(map[string][]PricePoint{CategoryIdTest: {{Date: trainedAt, Median: 10, Total: 1}}})
+	etag = get("", "").Header().Get("ETag")
+
+	t.Log("Given a new price history with the same model, the history ETag changes.", checkMark)
+	{
+		assert.Equal(t, http.StatusNotModified, get("If-None-Match", etag).Code)
+
+		s.SetInMemoryPriceHistory(map[string][]PricePoint{CategoryIdTest: {{Date: trainedAt, Median: 20, Total: 1}}})
+		assert.Equal(t, http.StatusOK, get("If-None-Match", etag).Code)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	t.Log("Given a SuggesterError, wrapped or not, ErrorCode returns its code.", checkMark)
+	assert.Equal(t, ERR_CATEGORY_NOT_FOUND, ErrorCode(errCategoryNotFound(CategoryIdTest)))
+	assert.Equal(t, ERR_MODEL_NOT_LOADED, ErrorCode(&CategoryError{Op: SUGGEST, CategoryId: CategoryIdTest, Err: ErrModelNotLoaded}))
+
+	t.Log("Given any other error, ErrorCode returns internal_error.", checkMark)
+	assert.Equal(t, ERR_INTERNAL, ErrorCode(errors.New("disk full")))
+}
+
 func BenchmarkSuggesterCtrl_SuggestPriceByCategory(b *testing.B) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
 
 	b.ResetTimer()
 
 	// Set dataTrained in Suggester
-	s := NewSuggester()
+	s := NewSuggester(config)
 	s.SetInMemoryDataTrained(dataTrainedTest)
 
 	// Make a new Suggester Controller or handlers
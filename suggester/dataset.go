@@ -0,0 +1,186 @@
+package suggester
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DATA_SET_FORMAT_JSON       string = "json"
+	DATA_SET_FORMAT_JSONL_GZIP string = "jsonl.gz"
+	DATA_SET_FILE_MODE                = 0644
+)
+
+// DataSetWriter saves the pages of items fetched for a category snapshot.
+type DataSetWriter interface {
+	WritePage(items []meli.SearchItem, offset int) error
+	Close() error
+}
+
+// jsonDataSetWriter writes one JSON array file per page: <category>-<offset>.json
+type jsonDataSetWriter struct {
+	snapshotFolder string
+	categoryId     string
+}
+
+// jsonlGzipDataSetWriter writes every page in one gzipped JSON Lines file: <category>.jsonl.gz
+type jsonlGzipDataSetWriter struct {
+	file    *os.File
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+}
+
+// NewDataSetWriter returns a writer of the format for a category snapshot folder.
+func NewDataSetWriter(format string, snapshotFolder string, categoryId string) (DataSetWriter, error) {
+	switch format {
+	case DATA_SET_FORMAT_JSON, "":
+		return &jsonDataSetWriter{snapshotFolder: snapshotFolder, categoryId: categoryId}, nil
+
+	case DATA_SET_FORMAT_JSONL_GZIP:
+		filePath := filepath.Join(snapshotFolder, categoryId+"."+DATA_SET_FORMAT_JSONL_GZIP)
+
+		file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, DATA_SET_FILE_MODE)
+
+		if err != nil {
+			return nil, err
+		}
+
+		gzipWriter := gzip.NewWriter(file)
+
+		return &jsonlGzipDataSetWriter{file: file, gzip: gzipWriter, encoder: json.NewEncoder(gzipWriter)}, nil
+
+	default:
+		return nil, errors.New(fmt.Sprintf("Data set format: %s is not supported.", format))
+	}
+}
+
+func (w *jsonDataSetWriter) WritePage(items []meli.SearchItem, offset int) error {
+	itemJson, err := json.Marshal(items)
+
+	if err != nil {
+		return err
+	}
+
+	fileDest := filepath.Join(w.snapshotFolder, fmt.Sprintf("%s-%d.json", w.categoryId, offset))
+
+	return ioutil.WriteFile(fileDest, itemJson, DATA_SET_FILE_MODE)
+}
+
+func (w *jsonDataSetWriter) Close() error {
+	return nil
+}
+
+func (w *jsonlGzipDataSetWriter) WritePage(items []meli.SearchItem, offset int) error {
+	for _, item := range items {
+		if err := w.encoder.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *jsonlGzipDataSetWriter) Close() error {
+	err := w.gzip.Close()
+
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// ReadDataSetFile reads the items of a data set file of any format.
+func ReadDataSetFile(filePath string) ([]meli.SearchItem, error) {
+	if strings.HasSuffix(filePath, "."+DATA_SET_FORMAT_JSONL_GZIP) {
+		return readJsonlGzipFile(filePath)
+	}
+
+	var items []meli.SearchItem
+
+	file, err := ioutil.ReadFile(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(file, &items)
+
+	return items, err
+}
+
+func readJsonlGzipFile(filePath string) ([]meli.SearchItem, error) {
+	var items []meli.SearchItem
+
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer gzipReader.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(gzipReader))
+
+	for decoder.More() {
+		var item meli.SearchItem
+
+		if err := decoder.Decode(&item); err != nil {
+			return items, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// dataSetFileFormat returns the format of a data set file, or false if it is not one.
+func dataSetFileFormat(name string) (string, bool) {
+	switch {
+	case strings.HasSuffix(name, "."+DATA_SET_FORMAT_JSONL_GZIP):
+		return DATA_SET_FORMAT_JSONL_GZIP, true
+	case strings.HasSuffix(name, "."+DATA_SET_FORMAT_JSON):
+		return DATA_SET_FORMAT_JSON, true
+	default:
+		return "", false
+	}
+}
+
+// ConvertDataSet rewrites every snapshot of a category in format. Only the
+// file storage keeps snapshots in files that can be converted.
+func (s *Suggester) ConvertDataSet(categoryId string, format string) error {
+	fileStorage, ok := s.storage.(*FileStorage)
+
+	if !ok {
+		return errors.New("Data set convert is only supported by the file storage.")
+	}
+
+	s.logger.Info(fmt.Sprintf("[ConvertDataSet][%s] Converting data set to: %s", categoryId, format))
+
+	return fileStorage.ConvertDataSet(categoryId, format)
+}
+
+func snapshotIsFormat(snapshot dataSetSnapshot, format string) bool {
+	for _, filePath := range snapshot.Files {
+		if fileFormat, _ := dataSetFileFormat(filePath); fileFormat != format {
+			return false
+		}
+	}
+	return true
+}
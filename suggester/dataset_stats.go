@@ -0,0 +1,201 @@
+package suggester
+
+import (
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	ISSUE_UNREADABLE        string = "unreadable"
+	ISSUE_EMPTY             string = "empty"
+	ISSUE_EMPTY_PAGES       string = "empty_pages"
+	ISSUE_CATEGORY_MISMATCH string = "category_mismatch"
+)
+
+// DataSetStats describes the latest snapshot of a category data set.
+type DataSetStats struct {
+	CategoryId   string            `json:"category_id"`
+	Snapshots    int               `json:"snapshots"`
+	SnapshotDate time.Time         `json:"snapshot_date"`
+	Files        int               `json:"files"`
+	Items        int               `json:"items"`
+	Currencies   map[string]int    `json:"currencies"`
+	Price        PriceDistribution `json:"price"`
+	// Fetch is how the snapshot was fetched, nil for snapshots fetched without info.
+	Fetch *SnapshotInfo `json:"fetch,omitempty"`
+	// Coverage is the share of the category total items in the snapshot.
+	Coverage float64 `json:"coverage"`
+}
+
+// PriceDistribution summarizes the prices of a snapshot.
+type PriceDistribution struct {
+	Min    float64 `json:"min"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+}
+
+// DataSetIssue is a problem found in a data set snapshot by ValidateDataSet.
+type DataSetIssue struct {
+	CategoryId   string    `json:"category_id"`
+	SnapshotDate time.Time `json:"snapshot_date"`
+	Source       string    `json:"source"`
+	Kind         string    `json:"kind"`
+	Message      string    `json:"message"`
+}
+
+// DataSetStats returns the stats of the latest snapshot of categoryId.
+func (s *Suggester) DataSetStats(categoryId string) (DataSetStats, error) {
+	stats := DataSetStats{CategoryId: categoryId, Currencies: make(map[string]int)}
+
+	dates, err := s.storage.Snapshots(categoryId)
+
+	if err != nil {
+		return stats, err
+	}
+
+	if len(dates) == 0 {
+		return stats, nil
+	}
+
+	stats.Snapshots = len(dates)
+	stats.SnapshotDate = dates[len(dates)-1]
+
+	var prices []float64
+
+	err = s.storage.ReadSnapshot(categoryId, stats.SnapshotDate, func(source string, items []meli.SearchItem, err error) {
+		stats.Files++
+
+		for _, item := range items {
+			stats.Currencies[item.Currency]++
+			prices = append(prices, item.Price)
+		}
+	})
+
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Items = len(prices)
+	stats.Price = priceDistribution(prices)
+
+	info, err := s.storage.ReadSnapshotInfo(categoryId, stats.SnapshotDate)
+
+	if err == nil {
+		stats.Fetch = &info
+
+		if info.TotalItems > 0 {
+			stats.Coverage = float64(stats.Items) / float64(info.TotalItems)
+		}
+	} else if !os.IsNotExist(err) {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// ValidateDataSet checks every snapshot of categoryId for unreadable sources,
+// empty pages and items of other categories.
+func (s *Suggester) ValidateDataSet(categoryId string) ([]DataSetIssue, error) {
+	var issues []DataSetIssue
+
+	dates, err := s.storage.Snapshots(categoryId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, date := range dates {
+		issue := DataSetIssue{CategoryId: categoryId, SnapshotDate: date}
+
+		err := s.storage.ReadSnapshot(categoryId, date, func(source string, items []meli.SearchItem, err error) {
+			issue.Source = source
+
+			if err != nil {
+				issue.Kind, issue.Message = ISSUE_UNREADABLE, err.Error()
+				issues = append(issues, issue)
+				return
+			}
+
+			if len(items) == 0 {
+				issue.Kind, issue.Message = ISSUE_EMPTY, "No items."
+				issues = append(issues, issue)
+				return
+			}
+
+			mismatched := make(map[string]int)
+			for _, item := range items {
+				if item.CategoryId != categoryId {
+					mismatched[item.CategoryId]++
+				}
+			}
+
+			if len(mismatched) > 0 {
+				issue.Kind, issue.Message = ISSUE_CATEGORY_MISMATCH, categoryMismatchMessage(mismatched, len(items))
+				issues = append(issues, issue)
+			}
+		})
+
+		if err != nil {
+			return issues, err
+		}
+
+		info, err := s.storage.ReadSnapshotInfo(categoryId, date)
+
+		if err == nil && info.EmptyPages > 0 {
+			issue.Source = ""
+			issue.Kind, issue.Message = ISSUE_EMPTY_PAGES, fmt.Sprintf("%d of %d pages fetched were empty.", info.EmptyPages, info.Pages)
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+func categoryMismatchMessage(mismatched map[string]int, total int) string {
+	categories := make([]string, 0, len(mismatched))
+	count := 0
+
+	for categoryId, items := range mismatched {
+		categories = append(categories, categoryId)
+		count += items
+	}
+
+	sort.Strings(categories)
+
+	return fmt.Sprintf("%d of %d items have another category_id: %v", count, total, categories)
+}
+
+func priceDistribution(prices []float64) PriceDistribution {
+	if len(prices) == 0 {
+		return PriceDistribution{}
+	}
+
+	sorted := make([]float64, len(prices))
+	copy(sorted, prices)
+	sort.Float64s(sorted)
+
+	return PriceDistribution{
+		Min:    sorted[0],
+		P25:    quantile(sorted, 0.25),
+		Median: median(sorted),
+		P75:    quantile(sorted, 0.75),
+		Max:    sorted[len(sorted)-1],
+		Mean:   mean(sorted),
+	}
+}
+
+// quantile interpolates the q quantile of sorted values.
+func quantile(sorted []float64, q float64) float64 {
+	position := q * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
@@ -0,0 +1,62 @@
+package suggester
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const CategoryIdDataSetStatsTest string = "MLA999008"
+
+func TestSuggester_DataSetStats(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdDataSetStatsTest, 10, 20, 30, 40, 50)
+
+	s := NewSuggester(config)
+	snapshot := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.storage.WriteSnapshotInfo(CategoryIdDataSetStatsTest, snapshot, SnapshotInfo{TotalItems: 20, SampleSize: 5, Pages: 2, EmptyPages: 1})
+
+	stats, err := s.DataSetStats(CategoryIdDataSetStatsTest)
+
+	assert.Nil(t, err)
+	t.Log("Given a snapshot with info, DataSetStats returns its items, prices and coverage.", checkMark)
+	assert.Equal(t, snapshot, stats.SnapshotDate)
+	assert.Equal(t, 1, stats.Files)
+	assert.Equal(t, 5, stats.Items)
+	assert.Equal(t, PriceDistribution{Min: 10, P25: 20, Median: 30, P75: 40, Max: 50, Mean: 30}, stats.Price)
+	assert.Equal(t, 0.25, stats.Coverage)
+	if assert.NotNil(t, stats.Fetch) {
+		assert.Equal(t, 5, stats.Fetch.SampleSize)
+	}
+}
+
+func TestSuggester_ValidateDataSet(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdDataSetStatsTest, 10, 20)
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdEvaluateTest, 30)
+
+	snapshotPath := filepath.Join(config.DataSetPath, CategoryIdDataSetStatsTest, "2018-01-01")
+	other, _ := ioutil.ReadFile(filepath.Join(config.DataSetPath, CategoryIdEvaluateTest, "2018-01-01", CategoryIdEvaluateTest+"-0.json"))
+	ioutil.WriteFile(filepath.Join(snapshotPath, CategoryIdDataSetStatsTest+"-50.json"), other, 0777)
+	ioutil.WriteFile(filepath.Join(snapshotPath, CategoryIdDataSetStatsTest+"-100.json"), []byte("[]"), 0777)
+	ioutil.WriteFile(filepath.Join(snapshotPath, CategoryIdDataSetStatsTest+"-150.json"), []byte("{"), 0777)
+
+	s := NewSuggester(config)
+	s.storage.WriteSnapshotInfo(CategoryIdDataSetStatsTest, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), SnapshotInfo{Pages: 4, EmptyPages: 1})
+
+	issues, err := s.ValidateDataSet(CategoryIdDataSetStatsTest)
+
+	assert.Nil(t, err)
+	t.Log("Given a snapshot with bad files, ValidateDataSet reports one issue per problem.", checkMark)
+	kinds := make(map[string]int)
+	for _, issue := range issues {
+		kinds[issue.Kind]++
+	}
+	assert.Equal(t, map[string]int{ISSUE_CATEGORY_MISMATCH: 1, ISSUE_EMPTY: 1, ISSUE_UNREADABLE: 1, ISSUE_EMPTY_PAGES: 1}, kinds)
+}
@@ -0,0 +1,108 @@
+package suggester
+
+import (
+	"encoding/json"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const CategoryIdDataSetTest string = "MLA999006"
+
+func TestDataSetWriter(t *testing.T) {
+	folder, _ := ioutil.TempDir("", "dataset")
+	defer os.RemoveAll(folder)
+
+	items := []meli.SearchItem{
+		{Id: "MLA1", Price: 10, CategoryId: CategoryIdDataSetTest},
+		{Id: "MLA2", Price: 20, CategoryId: CategoryIdDataSetTest},
+	}
+
+	for _, format := range []string{DATA_SET_FORMAT_JSON, DATA_SET_FORMAT_JSONL_GZIP} {
+		writer, err := NewDataSetWriter(format, folder, CategoryIdDataSetTest)
+		assert.Nil(t, err)
+
+		writer.WritePage(items[:1], 0)
+		writer.WritePage(items[1:], 50)
+		assert.Nil(t, writer.Close())
+
+		files, _ := listItemFiles(folder)
+
+		var read []meli.SearchItem
+		for _, filePath := range files {
+			if fileFormat, _ := dataSetFileFormat(filePath); fileFormat == format {
+				fileItems, err := ReadDataSetFile(filePath)
+				assert.Nil(t, err)
+				read = append(read, fileItems...)
+			}
+		}
+
+		t.Log("Given format ", format, " items written are read back.", checkMark)
+		assert.ElementsMatch(t, items, read)
+	}
+
+	_, err := NewDataSetWriter("xml", folder, CategoryIdDataSetTest)
+
+	t.Log("Given an unknown format, NewDataSetWriter returns error.", checkMark)
+	assert.NotNil(t, err)
+}
+
+func TestSuggester_ConvertDataSet(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	categoryPath := filepath.Join(config.DataSetPath, CategoryIdDataSetTest)
+
+	items := []meli.SearchItem{{Id: "MLA1", Price: 10, CategoryId: CategoryIdDataSetTest}}
+	itemsJson, _ := json.Marshal(items)
+
+	os.MkdirAll(categoryPath+"/2018-01-01", 0777)
+	ioutil.WriteFile(categoryPath+"/2018-01-01/"+CategoryIdDataSetTest+"-0.json", itemsJson, 0644)
+	ioutil.WriteFile(categoryPath+"/2018-01-01/"+CategoryIdDataSetTest+"-50.json", itemsJson, 0644)
+
+	s := NewSuggester(config)
+	s.storage.WriteSnapshotInfo(CategoryIdDataSetTest, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), SnapshotInfo{Items: 2})
+
+	err := s.ConvertDataSet(CategoryIdDataSetTest, DATA_SET_FORMAT_JSONL_GZIP)
+	assert.Nil(t, err)
+
+	snapshots, _ := NewFileStorage(config.DataSetPath, config.DataTrainedPath).listSnapshots(CategoryIdDataSetTest)
+
+	if assert.Len(t, snapshots, 1) && assert.Len(t, snapshots[0].Files, 1) {
+		t.Log("Given a json snapshot, ConvertDataSet writes one jsonl.gz file.", checkMark)
+		convertedItems, err := ReadDataSetFile(snapshots[0].Files[0])
+		assert.Nil(t, err)
+		assert.Len(t, convertedItems, 2)
+	}
+
+	info, _ := s.storage.ReadSnapshotInfo(CategoryIdDataSetTest, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err = os.Stat(categoryPath + "/2018-01-01.replaced")
+
+	t.Log("Given a snapshot converted, its info is kept and the replaced folder removed.", checkMark)
+	assert.Equal(t, 2, info.Items)
+	assert.True(t, os.IsNotExist(err))
+	// A file fetched before snapshots existed, the same day as the snapshot
+	legacyFile := categoryPath + "/" + CategoryIdDataSetTest + "-100.json"
+	ioutil.WriteFile(legacyFile, itemsJson, 0644)
+	os.Chtimes(legacyFile, time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC), time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	for run := 0; run < 2; run++ {
+		err = s.ConvertDataSet(CategoryIdDataSetTest, DATA_SET_FORMAT_JSONL_GZIP)
+		assert.Nil(t, err)
+	}
+
+	snapshots, _ = NewFileStorage(config.DataSetPath, config.DataTrainedPath).listSnapshots(CategoryIdDataSetTest)
+	_, err = os.Stat(legacyFile)
+
+	if assert.Len(t, snapshots, 1) && assert.Len(t, snapshots[0].Files, 1) {
+		t.Log("Given files in the category folder of a converted snapshot date, ConvertDataSet moves them into it once.", checkMark)
+		convertedItems, _ := ReadDataSetFile(snapshots[0].Files[0])
+		assert.Len(t, convertedItems, 3)
+		assert.Empty(t, snapshots[0].LegacyFiles)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
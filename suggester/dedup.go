@@ -0,0 +1,58 @@
+package suggester
+
+import (
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"strings"
+)
+
+// itemDeduplicator detects items already seen by id, and near-duplicates: the
+// same seller posting the same title in different listings.
+type itemDeduplicator struct {
+	ids            map[string]bool
+	listings       map[string]bool
+	Duplicates     int
+	NearDuplicates int
+}
+
+func newItemDeduplicator() *itemDeduplicator {
+	return &itemDeduplicator{
+		ids:      make(map[string]bool),
+		listings: make(map[string]bool),
+	}
+}
+
+// check registers item and returns whether it was already seen by id or it is a near-duplicate.
+func (d *itemDeduplicator) check(item meli.SearchItem) (bool, bool) {
+	if d.ids[item.Id] {
+		d.Duplicates++
+		return true, false
+	}
+	d.ids[item.Id] = true
+
+	key, ok := nearDuplicateKey(item)
+
+	if !ok {
+		return false, false
+	}
+
+	if d.listings[key] {
+		d.NearDuplicates++
+		return false, true
+	}
+	d.listings[key] = true
+
+	return false, false
+}
+
+// nearDuplicateKey returns the seller and normalized title of an item. Items
+// saved without seller can not be compared.
+func nearDuplicateKey(item meli.SearchItem) (string, bool) {
+	if item.Seller.Id == 0 || item.Title == "" {
+		return "", false
+	}
+
+	title := strings.Join(strings.Fields(strings.ToLower(item.Title)), " ")
+
+	return fmt.Sprintf("%d:%s", item.Seller.Id, title), true
+}
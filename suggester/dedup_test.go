@@ -0,0 +1,37 @@
+package suggester
+
+import (
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestItemDeduplicator_Check(t *testing.T) {
+	d := newItemDeduplicator()
+
+	item := meli.SearchItem{Id: "MLA1", Title: "Celular Libre", Seller: meli.SearchSeller{Id: 1}}
+	sameTitle := meli.SearchItem{Id: "MLA2", Title: "celular  libre", Seller: meli.SearchSeller{Id: 1}}
+	otherSeller := meli.SearchItem{Id: "MLA3", Title: "Celular Libre", Seller: meli.SearchSeller{Id: 2}}
+	withoutSeller := meli.SearchItem{Id: "MLA4", Title: "Celular Libre"}
+
+	duplicate, nearDuplicate := d.check(item)
+	assert.False(t, duplicate)
+	assert.False(t, nearDuplicate)
+
+	t.Log("Given an item already seen, check returns duplicate.", checkMark)
+	duplicate, _ = d.check(item)
+	assert.True(t, duplicate)
+
+	t.Log("Given the same seller and title, check returns near-duplicate.", checkMark)
+	_, nearDuplicate = d.check(sameTitle)
+	assert.True(t, nearDuplicate)
+
+	t.Log("Given other seller or no seller, check returns not duplicated.", checkMark)
+	_, nearDuplicate = d.check(otherSeller)
+	assert.False(t, nearDuplicate)
+	_, nearDuplicate = d.check(withoutSeller)
+	assert.False(t, nearDuplicate)
+
+	assert.Equal(t, 1, d.Duplicates)
+	assert.Equal(t, 1, d.NearDuplicates)
+}
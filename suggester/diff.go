@@ -0,0 +1,118 @@
+package suggester
+
+import (
+	"math"
+	"sort"
+)
+
+// ModelDiff describes how the categories moved between two trained models.
+type ModelDiff struct {
+	Added   []string              `json:"added"`
+	Removed []string              `json:"removed"`
+	Changes []CategoryPriceChange `json:"changes"`
+}
+
+// CategoryPriceChange is the change of a category present in both models.
+type CategoryPriceChange struct {
+	CategoryId string      `json:"category_id"`
+	Suggested  PriceChange `json:"suggested"`
+	Min        PriceChange `json:"min"`
+	Max        PriceChange `json:"max"`
+}
+
+// PriceChange is the absolute and relative change of a price. When the old
+// price is zero the relative change is 1 (or -1) if the new price is not zero.
+type PriceChange struct {
+	Old      float64 `json:"old"`
+	New      float64 `json:"new"`
+	Absolute float64 `json:"absolute"`
+	Relative float64 `json:"relative"`
+}
+
+// DiffModels compares two trained models. Changes are sorted by the largest
+// relative movement first.
+func DiffModels(oldModel map[string]CategoryPriceTrained, newModel map[string]CategoryPriceTrained) ModelDiff {
+	diff := ModelDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changes: []CategoryPriceChange{},
+	}
+
+	for categoryId, oldPrices := range oldModel {
+		newPrices, ok := newModel[categoryId]
+
+		if !ok {
+			diff.Removed = append(diff.Removed, categoryId)
+			continue
+		}
+
+		diff.Changes = append(diff.Changes, CategoryPriceChange{
+			CategoryId: categoryId,
+			Suggested:  newPriceChange(oldPrices.Suggested, newPrices.Suggested),
+			Min:        newPriceChange(oldPrices.Min, newPrices.Min),
+			Max:        newPriceChange(oldPrices.Max, newPrices.Max),
+		})
+	}
+
+	for categoryId := range newModel {
+		if _, ok := oldModel[categoryId]; !ok {
+			diff.Added = append(diff.Added, categoryId)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		movementI := diff.Changes[i].Movement()
+		movementJ := diff.Changes[j].Movement()
+
+		if movementI == movementJ {
+			return diff.Changes[i].CategoryId < diff.Changes[j].CategoryId
+		}
+		return movementI > movementJ
+	})
+
+	return diff
+}
+
+// LargestMovers returns up to n changes with the largest movement.
+func (d ModelDiff) LargestMovers(n int) []CategoryPriceChange {
+	if n < 0 || n > len(d.Changes) {
+		n = len(d.Changes)
+	}
+	return d.Changes[:n]
+}
+
+// ChangesExceeding returns the changes whose movement is greater than maxRelativeChange.
+func (d ModelDiff) ChangesExceeding(maxRelativeChange float64) []CategoryPriceChange {
+	var exceeding []CategoryPriceChange
+
+	for _, change := range d.Changes {
+		if change.Movement() > maxRelativeChange {
+			exceeding = append(exceeding, change)
+		}
+	}
+
+	return exceeding
+}
+
+// Movement is the largest absolute relative change among suggested, min and max.
+func (c CategoryPriceChange) Movement() float64 {
+	return math.Max(math.Abs(c.Suggested.Relative), math.Max(math.Abs(c.Min.Relative), math.Abs(c.Max.Relative)))
+}
+
+func newPriceChange(oldPrice float64, newPrice float64) PriceChange {
+	change := PriceChange{
+		Old:      oldPrice,
+		New:      newPrice,
+		Absolute: newPrice - oldPrice,
+	}
+
+	if oldPrice != 0 {
+		change.Relative = change.Absolute / math.Abs(oldPrice)
+	} else if newPrice != 0 {
+		change.Relative = math.Copysign(1, newPrice)
+	}
+
+	return change
+}
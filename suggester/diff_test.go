@@ -0,0 +1,43 @@
+package suggester
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiffModels(t *testing.T) {
+	oldModel := map[string]CategoryPriceTrained{
+		"MLA1": {Min: 10, Suggested: 20, Max: 30},
+		"MLA2": {Min: 10, Suggested: 100, Max: 200},
+		"MLA3": {Min: 1, Suggested: 1, Max: 1},
+	}
+	newModel := map[string]CategoryPriceTrained{
+		"MLA1": {Min: 10, Suggested: 22, Max: 30},
+		"MLA2": {Min: 10, Suggested: 150, Max: 200},
+		"MLA4": {Min: 1, Suggested: 1, Max: 1},
+	}
+
+	diff := DiffModels(oldModel, newModel)
+
+	t.Log("Given two models, DiffModels lists added and removed categories.", checkMark)
+	assert.Equal(t, []string{"MLA4"}, diff.Added)
+	assert.Equal(t, []string{"MLA3"}, diff.Removed)
+
+	t.Log("Given two models, DiffModels sorts changes by largest movement.", checkMark)
+	if assert.Len(t, diff.Changes, 2) {
+		assert.Equal(t, "MLA2", diff.Changes[0].CategoryId)
+		assert.Equal(t, 50.0, diff.Changes[0].Suggested.Absolute)
+		assert.Equal(t, 0.5, diff.Changes[0].Suggested.Relative)
+		assert.InDelta(t, 0.1, diff.Changes[1].Suggested.Relative, 0.0001)
+	}
+
+	assert.Len(t, diff.LargestMovers(1), 1)
+	assert.Len(t, diff.ChangesExceeding(0.2), 1)
+}
+
+func TestNewPriceChangeFromZero(t *testing.T) {
+	change := newPriceChange(0, 50)
+
+	t.Log("Given an old price of zero, relative change is 1.", checkMark)
+	assert.Equal(t, 1.0, change.Relative)
+}
@@ -0,0 +1,105 @@
+package suggester
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Codes of the errors clients of the suggester can handle.
+const (
+	ERR_CATEGORY_NOT_FOUND  string = "category_not_found"
+	ERR_MODEL_NOT_LOADED    string = "model_not_loaded"
+	ERR_INVALID_CATEGORY_ID string = "invalid_category_id"
+	ERR_INSUFFICIENT_DATA   string = "insufficient_data"
+	ERR_INVALID_REQUEST     string = "invalid_request"
+	ERR_UNAUTHORIZED        string = "unauthorized"
+	ERR_FORBIDDEN           string = "forbidden"
+	ERR_RATE_LIMITED        string = "rate_limited"
+	ERR_JOB_NOT_FOUND       string = "job_not_found"
+	ERR_JOB_CONFLICT        string = "job_conflict"
+	ERR_INTERNAL            string = "internal_error"
+)
+
+// ErrModelNotLoaded is returned while no data trained is loaded.
+var ErrModelNotLoaded error = &SuggesterError{Code: ERR_MODEL_NOT_LOADED, Message: "Data trained not loaded."}
+
+// SuggesterError is an error clients can handle by its Code.
+type SuggesterError struct {
+	Code    string
+	Message string
+}
+
+func (e *SuggesterError) Error() string {
+	return e.Message
+}
+
+func newSuggesterError(code string, message string) *SuggesterError {
+	return &SuggesterError{Code: code, Message: message}
+}
+
+func errCategoryNotFound(categoryId string) error {
+	return newSuggesterError(ERR_CATEGORY_NOT_FOUND, fmt.Sprintf("Category: %s not found.", categoryId))
+}
+
+// ErrorCode returns the code of err, ERR_INTERNAL when it is not a SuggesterError.
+func ErrorCode(err error) string {
+	switch e := err.(type) {
+	case *SuggesterError:
+		return e.Code
+	case *CategoryError:
+		return ErrorCode(e.Err)
+	}
+
+	return ERR_INTERNAL
+}
+
+// CategoryError is the failure of an operation on a category.
+type CategoryError struct {
+	Op         string
+	CategoryId string
+	Err        error
+}
+
+func (e *CategoryError) Error() string {
+	return fmt.Sprintf("%s category: %s %s", e.Op, e.CategoryId, e.Err)
+}
+
+// CategoryErrors are the categories an operation failed for, while the
+// operation went on with the rest of them.
+type CategoryErrors []*CategoryError
+
+func (e CategoryErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("%d categories failed:\n%s", len(e), strings.Join(messages, "\n"))
+}
+
+// Categories returns the ids of the categories that failed.
+func (e CategoryErrors) Categories() []string {
+	categories := make([]string, 0, len(e))
+	for _, err := range e {
+		categories = append(categories, err.CategoryId)
+	}
+
+	sort.Strings(categories)
+
+	return categories
+}
+
+// errorOrNil returns nil when no category failed, so an empty CategoryErrors
+// is not returned as a non-nil error.
+func (e CategoryErrors) errorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	sort.Slice(e, func(i, j int) bool {
+		return e[i].CategoryId < e[j].CategoryId
+	})
+
+	return e
+}
@@ -0,0 +1,275 @@
+package suggester
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const DEFAULT_HOLDOUT_RATIO float64 = 0.2
+
+// EvaluationReport holds the result of an offline holdout backtest.
+type EvaluationReport struct {
+	GeneratedAt  time.Time                    `json:"generated_at"`
+	HoldoutRatio float64                      `json:"holdout_ratio"`
+	Seed         int64                        `json:"seed"`
+	Overall      EvaluationMetrics            `json:"overall"`
+	Categories   map[string]EvaluationMetrics `json:"categories"`
+	// UnreadableFiles are the data set sources skipped because they could not be read.
+	UnreadableFiles int `json:"unreadable_files"`
+}
+
+// EvaluationMetrics are the error metrics of the suggestions against the held-out prices.
+type EvaluationMetrics struct {
+	TrainSize   int     `json:"train_size"`
+	HoldoutSize int     `json:"holdout_size"`
+	MAE         float64 `json:"mae"`
+	MAPE        float64 `json:"mape"`
+	MedianAPE   float64 `json:"median_ape"`
+	Coverage    float64 `json:"coverage"`
+}
+
+// evaluationAccumulator collects the errors needed to compute EvaluationMetrics.
+type evaluationAccumulator struct {
+	trainSize      int
+	holdoutSize    int
+	absoluteErrors []float64
+	percentErrors  []float64
+	covered        int
+}
+
+// Evaluate splits the data set per category in train and holdout sets, trains
+// with the first one and scores the suggestions against the held-out prices.
+// The same seed splits the same data set the same way. The folders that can
+// not be read are returned as CategoryErrors after evaluating the rest.
+func (s *Suggester) Evaluate(holdoutRatio float64, seed int64) (EvaluationReport, error) {
+	report := EvaluationReport{
+		GeneratedAt:  s.now(),
+		HoldoutRatio: holdoutRatio,
+		Seed:         seed,
+		Categories:   make(map[string]EvaluationMetrics),
+	}
+
+	if holdoutRatio <= 0 || holdoutRatio >= 1 {
+		return report, fmt.Errorf("Holdout ratio must be between 0 and 1, got: %f", holdoutRatio)
+	}
+
+	items, unreadableFiles, err := s.readDataSetItems()
+
+	if _, partial := err.(CategoryErrors); err != nil && !partial {
+		return report, err
+	}
+
+	report.UnreadableFiles = unreadableFiles
+
+	s.logger.Info(fmt.Sprintf("[Evaluate] Items read: %d", len(items)))
+
+	r := rand.New(rand.NewSource(seed))
+
+	overall := &evaluationAccumulator{}
+
+	for _, categoryId := range sortedItemCategories(items) {
+		categoryItems := items[categoryId]
+
+		r.Shuffle(len(categoryItems), func(i, j int) {
+			categoryItems[i], categoryItems[j] = categoryItems[j], categoryItems[i]
+		})
+
+		train, holdout := splitHoldout(categoryItems, holdoutRatio)
+
+		var trained CategoryPriceTrained
+		for index, item := range train {
+			trained = addPriceToCategoryTrained(trained, index > 0, item.Price)
+		}
+
+		accumulator := &evaluationAccumulator{trainSize: len(train)}
+		for _, item := range holdout {
+			accumulator.add(trained, item.Price)
+		}
+
+		overall.merge(accumulator)
+		report.Categories[categoryId] = accumulator.metrics()
+	}
+
+	report.Overall = overall.metrics()
+
+	s.logger.Info("[Evaluate] Evaluation finished")
+
+	return report, err
+}
+
+// readDataSetItems reads every item of the data set grouped by the categories
+// the configured attribution trains it into, sorted by id. It returns the
+// number of unreadable files, and the folders that can not be read as
+// CategoryErrors.
+func (s *Suggester) readDataSetItems() (map[string][]meli.SearchItem, int, error) {
+	items := make(map[string][]meli.SearchItem)
+
+	categories, err := s.storage.DataSetCategories()
+
+	if err != nil {
+		s.logger.Warning("[readDataSetItems] Error reading dataset categories.")
+		return items, 0, err
+	}
+
+	attribution := attribution{policy: s.config.Attribution, tree: s.loadCategoryTree(categories)}
+
+	wgItemProducer := &sync.WaitGroup{}
+	outPutItemChannel := make(chan *dataSetItem, 20)
+	done := make(chan struct{})
+
+	go func() {
+		deduplicators := make(map[string]*itemDeduplicator)
+
+		for item := range outPutItemChannel {
+			if deduplicators[item.Folder] == nil {
+				deduplicators[item.Folder] = newItemDeduplicator()
+			}
+
+			// Duplicated items would leak between train and holdout sets
+			if duplicate, _ := deduplicators[item.Folder].check(item.SearchItem); duplicate {
+				continue
+			}
+
+			for _, categoryId := range attribution.categories(item.Folder, item.CategoryId) {
+				items[categoryId] = append(items[categoryId], item.SearchItem)
+			}
+		}
+		close(done)
+	}()
+
+	reports := make(map[string]*CategoryTrainReport)
+
+	for _, categoryId := range categories {
+		reports[categoryId] = newCategoryTrainReport()
+
+		wgItemProducer.Add(1)
+		go s.readItemFilesForCategory(categoryId, reports[categoryId], nil, outPutItemChannel, wgItemProducer)
+	}
+
+	wgItemProducer.Wait()
+	close(outPutItemChannel)
+	<-done
+
+	// Folders are read concurrently, sorted the items are shuffled the same by the same seed
+	for _, categoryItems := range items {
+		sort.Slice(categoryItems, func(i, j int) bool {
+			if categoryItems[i].Id != categoryItems[j].Id {
+				return categoryItems[i].Id < categoryItems[j].Id
+			}
+			return categoryItems[i].Price < categoryItems[j].Price
+		})
+	}
+
+	var categoryErrors CategoryErrors
+	unreadableFiles := 0
+
+	for categoryId, report := range reports {
+		unreadableFiles += len(report.UnreadableFiles)
+
+		if report.Error != "" {
+			categoryErrors = append(categoryErrors, &CategoryError{Op: EVALUATE, CategoryId: categoryId, Err: errors.New(report.Error)})
+		}
+	}
+
+	return items, unreadableFiles, categoryErrors.errorOrNil()
+}
+
+// splitHoldout splits items keeping at least one item for training.
+func splitHoldout(items []meli.SearchItem, holdoutRatio float64) ([]meli.SearchItem, []meli.SearchItem) {
+	holdoutSize := int(math.Round(float64(len(items)) * holdoutRatio))
+
+	if holdoutSize >= len(items) {
+		holdoutSize = len(items) - 1
+	}
+
+	if holdoutSize < 0 {
+		holdoutSize = 0
+	}
+
+	trainSize := len(items) - holdoutSize
+
+	return items[:trainSize], items[trainSize:]
+}
+
+func sortedItemCategories(items map[string][]meli.SearchItem) []string {
+	categories := make([]string, 0, len(items))
+	for categoryId := range items {
+		categories = append(categories, categoryId)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+func (a *evaluationAccumulator) add(trained CategoryPriceTrained, price float64) {
+	a.holdoutSize++
+
+	absoluteError := math.Abs(trained.Suggested - price)
+	a.absoluteErrors = append(a.absoluteErrors, absoluteError)
+
+	// Percentage errors are undefined for free items
+	if price != 0 {
+		a.percentErrors = append(a.percentErrors, absoluteError/math.Abs(price))
+	}
+
+	if price >= trained.Min && price <= trained.Max {
+		a.covered++
+	}
+}
+
+func (a *evaluationAccumulator) merge(other *evaluationAccumulator) {
+	a.trainSize += other.trainSize
+	a.holdoutSize += other.holdoutSize
+	a.absoluteErrors = append(a.absoluteErrors, other.absoluteErrors...)
+	a.percentErrors = append(a.percentErrors, other.percentErrors...)
+	a.covered += other.covered
+}
+
+func (a *evaluationAccumulator) metrics() EvaluationMetrics {
+	metrics := EvaluationMetrics{
+		TrainSize:   a.trainSize,
+		HoldoutSize: a.holdoutSize,
+		MAE:         mean(a.absoluteErrors),
+		MAPE:        mean(a.percentErrors),
+		MedianAPE:   median(a.percentErrors),
+	}
+
+	if scored := len(a.absoluteErrors); scored > 0 {
+		metrics.Coverage = float64(a.covered) / float64(scored)
+	}
+
+	return metrics
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,139 @@
+package suggester
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const CategoryIdEvaluateTest string = "MLA999001"
+
+func TestSuggester_Evaluate(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	// Prepare a data set with ten items priced 100, 110, ..., 190
+	var items []meli.SearchItem
+	for i := 0; i < 10; i++ {
+		items = append(items, meli.SearchItem{
+			Id:         fmt.Sprintf("MLA%d", i),
+			Price:      float64(100 + i*10),
+			CategoryId: CategoryIdEvaluateTest,
+		})
+	}
+
+	categoryPath := filepath.Join(config.DataSetPath, CategoryIdEvaluateTest)
+	os.MkdirAll(categoryPath, 0777)
+
+	itemsJson, _ := json.Marshal(items)
+	ioutil.WriteFile(categoryPath+"/"+CategoryIdEvaluateTest+"-0.json", itemsJson, 0777)
+
+	s := NewSuggester(config)
+
+	report, err := s.Evaluate(0.2, 1)
+
+	assert.Nil(t, err)
+
+	metrics, ok := report.Categories[CategoryIdEvaluateTest]
+	if assert.True(t, ok) {
+		t.Log("Given a holdout ratio 0.2, Evaluate holds out 2 of 10 items.", checkMark)
+		assert.Equal(t, 8, metrics.TrainSize)
+		assert.Equal(t, 2, metrics.HoldoutSize)
+		assert.True(t, metrics.MAE >= 0)
+		assert.True(t, metrics.Coverage >= 0 && metrics.Coverage <= 1)
+	}
+
+	assert.True(t, report.Overall.HoldoutSize >= metrics.HoldoutSize)
+}
+
+func TestSuggester_EvaluateReadErrors(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	config.Attribution = ATTRIBUTION_TREE
+
+	storage := failingReadStorage{Storage: NewFileStorage(config.DataSetPath, config.DataTrainedPath), categoryId: CategoryIdAttributionSibling}
+	s := NewSuggester(config, WithStorage(storage))
+
+	writeAttributionTestDataSet(s, CategoryIdAttributionChild,
+		[]string{CategoryIdAttributionRoot, CategoryIdAttributionParent, CategoryIdAttributionChild},
+		meli.SearchItem{Id: "MLA4", Price: 40, CategoryId: CategoryIdAttributionChild},
+		meli.SearchItem{Id: "MLA2", Price: 20, CategoryId: CategoryIdAttributionChild})
+	writeAttributionTestDataSet(s, CategoryIdAttributionParent,
+		[]string{CategoryIdAttributionRoot, CategoryIdAttributionParent},
+		meli.SearchItem{Id: "MLA3", Price: 30, CategoryId: CategoryIdAttributionParent},
+		meli.SearchItem{Id: "MLA1", Price: 10, CategoryId: CategoryIdAttributionParent})
+	writeAttributionTestDataSet(s, CategoryIdAttributionSibling,
+		[]string{CategoryIdAttributionRoot, CategoryIdAttributionSibling},
+		meli.SearchItem{Id: "MLA5", Price: 50, CategoryId: CategoryIdAttributionSibling})
+
+	snapshotFolder := filepath.Join(config.DataSetPath, CategoryIdAttributionParent, "2018-01-01")
+	ioutil.WriteFile(snapshotFolder+"/"+CategoryIdAttributionParent+"-50.json", []byte("{"), 0777)
+
+	items, unreadableFiles, err := s.readDataSetItems()
+
+	t.Log("Given folders read concurrently, the items of a category are sorted by id.", checkMark)
+	var ids []string
+	for _, item := range items[CategoryIdAttributionRoot] {
+		ids = append(ids, item.Id)
+	}
+	assert.Equal(t, []string{"MLA1", "MLA2", "MLA3", "MLA4"}, ids)
+
+	t.Log("Given an unreadable file and a folder that can not be read, they are reported.", checkMark)
+	assert.Equal(t, 1, unreadableFiles)
+	if assert.IsType(t, CategoryErrors{}, err) {
+		assert.Equal(t, []string{CategoryIdAttributionSibling}, err.(CategoryErrors).Categories())
+	}
+
+	report, err := s.Evaluate(0.5, 1)
+	again, _ := s.Evaluate(0.5, 1)
+
+	t.Log("Given the same seed, Evaluate returns the same report with the folders not read.", checkMark)
+	assert.IsType(t, CategoryErrors{}, err)
+	assert.Equal(t, 1, report.UnreadableFiles)
+	assert.Equal(t, report.Categories, again.Categories)
+}
+
+func TestSuggester_EvaluateInvalidRatio(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := NewSuggester(config)
+
+	_, err := s.Evaluate(1.5, 1)
+
+	t.Log("Given a holdout ratio out of range, Evaluate returns error.", checkMark)
+	assert.NotNil(t, err)
+}
+
+func TestSplitHoldout(t *testing.T) {
+	items := make([]meli.SearchItem, 3)
+
+	train, holdout := splitHoldout(items, 0.9)
+
+	t.Log("splitHoldout keeps at least one item for training.", checkMark)
+	assert.Len(t, train, 1)
+	assert.Len(t, holdout, 2)
+}
+
+func TestEvaluationAccumulator_Metrics(t *testing.T) {
+	trained := CategoryPriceTrained{Min: 80, Suggested: 100, Max: 120}
+
+	accumulator := &evaluationAccumulator{}
+	accumulator.add(trained, 80)
+	accumulator.add(trained, 200)
+
+	metrics := accumulator.metrics()
+
+	t.Log("Given holdout prices 80 and 200 for a suggestion of 100, metrics are computed.", checkMark)
+	assert.Equal(t, 2, metrics.HoldoutSize)
+	assert.Equal(t, 60.0, metrics.MAE)
+	assert.InDelta(t, 0.375, metrics.MAPE, 0.0001)
+	assert.InDelta(t, 0.375, metrics.MedianAPE, 0.0001)
+	assert.Equal(t, 0.5, metrics.Coverage)
+}
@@ -0,0 +1,311 @@
+package suggester
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	JOB_RUNNING   string = "running"
+	JOB_SUCCEEDED string = "succeeded"
+	// JOB_PARTIAL is a job whose failed categories are in its errors, the rest were fetched or trained.
+	JOB_PARTIAL string = "partially_succeeded"
+	JOB_FAILED  string = "failed"
+)
+
+// Job is a fetch or train run in background by the server.
+type Job struct {
+	Id     string `json:"id"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	// Progress is the categories fetched or read for training.
+	Progress   JobProgress `json:"progress"`
+	Errors     []string    `json:"errors,omitempty"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+type JobProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// FetchJobRequest selects the categories to fetch, every category of the site if empty.
+type FetchJobRequest struct {
+	Categories []string `json:"categories"`
+}
+
+// TrainJobRequest are the TrainOptions of a train job.
+type TrainJobRequest struct {
+	Categories         []string `json:"categories"`
+	Incremental        bool     `json:"incremental"`
+	DropNearDuplicates bool     `json:"drop_near_duplicates"`
+}
+
+// jobEventsBuffer is how many progress events a subscriber can fall behind
+// before the next ones are dropped for it.
+const jobEventsBuffer = 256
+
+// maxFinishedJobs is how many finished jobs are kept, the oldest ones are forgotten first.
+const maxFinishedJobs = 100
+
+// Jobs runs fetch and train jobs on the suggester, one at a time since they
+// write the same data set and model. The model is reloaded when a train job
+// succeeds, even partially. The last maxFinishedJobs finished jobs are kept.
+type Jobs struct {
+	sync.Mutex
+	suggester *Suggester
+	jobs      map[string]*Job
+	running   *Job
+	// finished are the ids of the finished jobs, oldest first.
+	finished []string
+	// done is closed when the job of its id finishes.
+	done map[string]chan struct{}
+	// subscribers receive the progress events of the running job.
+	subscribers map[chan ProgressEvent]bool
+}
+
+// NewJobs returns the jobs of s, which observe its progress.
+func NewJobs(s *Suggester) *Jobs {
+	j := &Jobs{
+		suggester:   s,
+		jobs:        make(map[string]*Job),
+		done:        make(map[string]chan struct{}),
+		subscribers: make(map[chan ProgressEvent]bool),
+	}
+
+	s.AddObserver(j)
+
+	return j
+}
+
+// StartFetch starts fetching the categories of request from the configured site.
+func (j *Jobs) StartFetch(request FetchJobRequest) (Job, error) {
+	s := j.suggester
+
+	for _, categoryId := range request.Categories {
+		if err := s.ValidateCategoryId(categoryId); err != nil {
+			return Job{}, err
+		}
+	}
+
+	return j.start(FETCH_DATA_SET, func() error {
+		if len(request.Categories) > 0 {
+			return s.FetchCategories(s.config.Site, request.Categories)
+		}
+		return s.FetchDataSet(s.config.Site)
+	})
+}
+
+// StartTrain starts training the data set folders selected by request.
+func (j *Jobs) StartTrain(request TrainJobRequest) (Job, error) {
+	s := j.suggester
+
+	return j.start(TRAIN_MODEL, func() error {
+		_, err := s.TrainWithOptions(TrainOptions{
+			Categories:         request.Categories,
+			Incremental:        request.Incremental,
+			DropNearDuplicates: request.DropNearDuplicates,
+		})
+		return err
+	})
+}
+
+func (j *Jobs) start(kind string, run func() error) (Job, error) {
+	j.Lock()
+	defer j.Unlock()
+
+	if j.running != nil {
+		return Job{}, newSuggesterError(ERR_JOB_CONFLICT, fmt.Sprintf("Job: %s %s is running.", j.running.Kind, j.running.Id))
+	}
+
+	job := &Job{
+		Id:        newId(),
+		Kind:      kind,
+		Status:    JOB_RUNNING,
+		StartedAt: j.suggester.now(),
+	}
+
+	j.jobs[job.Id] = job
+	j.done[job.Id] = make(chan struct{})
+	j.running = job
+
+	go j.run(job, run)
+
+	return *job, nil
+}
+
+func (j *Jobs) run(job *Job, run func() error) {
+	j.suggester.logger.Info(fmt.Sprintf("[Jobs] Starting %s job: %s", job.Kind, job.Id))
+
+	status, err := j.execute(job.Kind, run)
+
+	j.Lock()
+	defer j.Unlock()
+
+	finishedAt := j.suggester.now()
+	job.FinishedAt = &finishedAt
+	job.Status = status
+
+	if err != nil {
+		job.Errors = jobErrors(err)
+	}
+
+	j.running = nil
+	close(j.done[job.Id])
+	j.forgetFinished(job.Id)
+
+	for events := range j.subscribers {
+		delete(j.subscribers, events)
+		close(events)
+	}
+
+	j.suggester.logger.Info(fmt.Sprintf("[Jobs] Finished %s job: %s %s", job.Kind, job.Id, job.Status))
+}
+
+// execute runs a job of kind and reloads the model when it trained one, even
+// if some categories failed. A fetch leaves the model as it is. A panic fails
+// the job instead of leaving it running.
+func (j *Jobs) execute(kind string, run func() error) (status string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			status, err = JOB_FAILED, errors.New(fmt.Sprintf("Job panicked: %v", recovered))
+		}
+	}()
+
+	err = run()
+
+	_, partial := err.(CategoryErrors)
+
+	if err != nil && !partial {
+		return JOB_FAILED, err
+	}
+
+	if kind == TRAIN_MODEL {
+		if loadErr := j.suggester.LoadModel(); loadErr != nil {
+			return JOB_FAILED, loadErr
+		}
+	}
+
+	if partial {
+		return JOB_PARTIAL, err
+	}
+
+	return JOB_SUCCEEDED, nil
+}
+
+// forgetFinished records the job of id as finished, and forgets the oldest
+// finished jobs over maxFinishedJobs.
+func (j *Jobs) forgetFinished(id string) {
+	j.finished = append(j.finished, id)
+
+	for len(j.finished) > maxFinishedJobs {
+		delete(j.jobs, j.finished[0])
+		delete(j.done, j.finished[0])
+		j.finished = j.finished[1:]
+	}
+}
+
+// Progress records the progress of the running job and sends the event to
+// its subscribers, dropping it for the ones that fell behind.
+func (j *Jobs) Progress(event ProgressEvent) {
+	j.Lock()
+	defer j.Unlock()
+
+	job := j.running
+
+	if job == nil {
+		return
+	}
+
+	// Categories read concurrently can report out of order
+	if event.Total != job.Progress.Total || event.Done > job.Progress.Done {
+		job.Progress = JobProgress{Done: event.Done, Total: event.Total}
+	}
+
+	for events := range j.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Events subscribes to the progress events of the job of id. The channel is
+// closed when the job finishes, right away if it did, or when cancel is called.
+func (j *Jobs) Events(id string) (<-chan ProgressEvent, func(), error) {
+	j.Lock()
+	defer j.Unlock()
+
+	if _, ok := j.jobs[id]; !ok {
+		return nil, nil, newSuggesterError(ERR_JOB_NOT_FOUND, fmt.Sprintf("Job: %s not found.", id))
+	}
+
+	events := make(chan ProgressEvent, jobEventsBuffer)
+
+	if j.running == nil || j.running.Id != id {
+		close(events)
+		return events, func() {}, nil
+	}
+
+	j.subscribers[events] = true
+
+	cancel := func() {
+		j.Lock()
+		defer j.Unlock()
+
+		if j.subscribers[events] {
+			delete(j.subscribers, events)
+			close(events)
+		}
+	}
+
+	return events, cancel, nil
+}
+
+// jobErrors lists the failed categories one by one.
+func jobErrors(err error) []string {
+	categoryErrors, ok := err.(CategoryErrors)
+
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	errs := make([]string, 0, len(categoryErrors))
+	for _, categoryError := range categoryErrors {
+		errs = append(errs, categoryError.Error())
+	}
+
+	return errs
+}
+
+// Get returns the job of id.
+func (j *Jobs) Get(id string) (Job, error) {
+	j.Lock()
+	defer j.Unlock()
+
+	job, ok := j.jobs[id]
+
+	if !ok {
+		return Job{}, newSuggesterError(ERR_JOB_NOT_FOUND, fmt.Sprintf("Job: %s not found.", id))
+	}
+
+	return *job, nil
+}
+
+// Wait waits for the job of id to finish and returns it.
+func (j *Jobs) Wait(id string) (Job, error) {
+	j.Lock()
+	done, ok := j.done[id]
+	j.Unlock()
+
+	if !ok {
+		return Job{}, newSuggesterError(ERR_JOB_NOT_FOUND, fmt.Sprintf("Job: %s not found.", id))
+	}
+
+	<-done
+
+	return j.Get(id)
+}
@@ -0,0 +1,329 @@
+package suggester
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobs_StartTrain(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := NewSuggester(config)
+	writeAttributionTestDataSet(s, CategoryIdAttributionParent, nil,
+		meli.SearchItem{Id: "MLA1", Price: 10, CategoryId: CategoryIdAttributionParent})
+	writeAttributionTestDataSet(s, CategoryIdAttributionSibling, nil,
+		meli.SearchItem{Id: "MLA2", Price: 50, CategoryId: CategoryIdAttributionSibling})
+
+	// A second snapshot to adjust by trend
+	writer, _ := s.storage.NewDataSetWriter(CategoryIdAttributionSibling, time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC), DATA_SET_FORMAT_JSON)
+	writer.WritePage([]meli.SearchItem{{Id: "MLA2", Price: 50, CategoryId: CategoryIdAttributionSibling}}, 0)
+	writer.Commit(SnapshotInfo{Items: 1})
+
+	jobs := NewJobs(s)
+
+	t.Log("Given a train job, it trains every category and reloads the model.", checkMark)
+	{
+		job, err := jobs.StartTrain(TrainJobRequest{})
+		assert.Nil(t, err)
+		assert.Equal(t, JOB_RUNNING, job.Status)
+
+		job, err = jobs.Wait(job.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, JOB_SUCCEEDED, job.Status)
+		assert.Equal(t, JobProgress{Done: 2, Total: 2}, job.Progress)
+		assert.NotNil(t, job.FinishedAt)
+
+		suggested, err := s.Suggest(CategoryIdAttributionSibling)
+		assert.Nil(t, err)
+		assert.Equal(t, 50.0, suggested.Suggested)
+	}
+
+	t.Log("Given a train job, prices are suggested while it reloads the model.", checkMark)
+	{
+		job, _ := jobs.StartTrain(TrainJobRequest{})
+		done := make(chan bool)
+
+		go func() {
+			jobs.Wait(job.Id)
+			close(done)
+		}()
+
+		for finished := false; !finished; {
+			select {
+			case <-done:
+				finished = true
+			default:
+				suggested, err := s.SuggestAdjusted(CategoryIdAttributionSibling, ADJUSTMENT_TREND)
+				assert.Nil(t, err)
+				assert.Equal(t, 50.0, suggested.Suggested)
+			}
+		}
+	}
+
+	t.Log("Given a running job, another one conflicts.", checkMark)
+	{
+		release := make(chan bool)
+		job, err := jobs.start(FETCH_DATA_SET, func() error {
+			<-release
+			return nil
+		})
+		assert.Nil(t, err)
+
+		_, err = jobs.StartTrain(TrainJobRequest{})
+		assert.Equal(t, ERR_JOB_CONFLICT, ErrorCode(err))
+
+		close(release)
+		jobs.Wait(job.Id)
+
+		job, err = jobs.StartTrain(TrainJobRequest{})
+		assert.Nil(t, err)
+		jobs.Wait(job.Id)
+	}
+
+	t.Log("Given a job with failed categories, it partially succeeds with their errors.", checkMark)
+	{
+		job, _ := jobs.start(TRAIN_MODEL, func() error {
+			return CategoryErrors{{Op: TRAIN_MODEL, CategoryId: CategoryIdAttributionChild, Err: errors.New("Unreadable.")}}
+		})
+
+		job, _ = jobs.Wait(job.Id)
+		assert.Equal(t, JOB_PARTIAL, job.Status)
+		assert.Len(t, job.Errors, 1)
+	}
+
+	t.Log("Given a job that panics, it fails and the next one can start.", checkMark)
+	{
+		job, _ := jobs.start(TRAIN_MODEL, func() error {
+			panic("train")
+		})
+
+		job, _ = jobs.Wait(job.Id)
+		assert.Equal(t, JOB_FAILED, job.Status)
+		assert.Equal(t, []string{"Job panicked: train"}, job.Errors)
+
+		job, err := jobs.start(TRAIN_MODEL, func() error { return nil })
+		assert.Nil(t, err)
+		jobs.Wait(job.Id)
+	}
+
+	t.Log("Given more finished jobs than kept, the oldest ones are forgotten.", checkMark)
+	{
+		first, _ := jobs.start(TRAIN_MODEL, func() error { return errors.New("Failed.") })
+		jobs.Wait(first.Id)
+
+		var last Job
+		for i := 0; i < maxFinishedJobs; i++ {
+			last, _ = jobs.start(TRAIN_MODEL, func() error { return errors.New("Failed.") })
+			jobs.Wait(last.Id)
+		}
+
+		_, err := jobs.Get(first.Id)
+		assert.Equal(t, ERR_JOB_NOT_FOUND, ErrorCode(err))
+		_, err = jobs.Get(last.Id)
+		assert.Nil(t, err)
+	}
+
+	t.Log("Given a malformed category to fetch or an unknown job, it fails.", checkMark)
+	{
+		_, err := jobs.StartFetch(FetchJobRequest{Categories: []string{"MLB1051"}})
+		assert.Equal(t, ERR_INVALID_CATEGORY_ID, ErrorCode(err))
+
+		_, err = jobs.Get("unknown")
+		assert.Equal(t, ERR_JOB_NOT_FOUND, ErrorCode(err))
+	}
+}
+
+func TestJobs_StartFetch(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := NewSuggester(config, WithMeliClient(&meliClientTest{total: 100}))
+	jobs := NewJobs(s)
+
+	t.Log("Given a fetch job and no model trained, it fetches the data set and succeeds.", checkMark)
+	{
+		job, err := jobs.StartFetch(FetchJobRequest{Categories: []string{CategoryIdTest}})
+		assert.Nil(t, err)
+
+		job, _ = jobs.Wait(job.Id)
+		assert.Equal(t, JOB_SUCCEEDED, job.Status)
+		assert.Empty(t, job.Errors)
+
+		categories, _ := s.storage.DataSetCategories()
+		assert.Equal(t, []string{CategoryIdTest}, categories)
+	}
+}
+
+func TestJobsCtrl(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := NewSuggester(config)
+	writeAttributionTestDataSet(s, CategoryIdAttributionParent, nil,
+		meli.SearchItem{Id: "MLA1", Price: 10, CategoryId: CategoryIdAttributionParent})
+
+	jobs := NewJobs(s)
+	jobsCtrl := NewJobsCtrl(jobs)
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/admin/jobs/train", jobsCtrl.StartTrain)
+	router.GET("/admin/jobs/:jobId", jobsCtrl.JobById)
+
+	request := func(method string, url string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Log("Given a train request, it returns 202 with the job and its location.", checkMark)
+	{
+		resp := request("POST", "/admin/jobs/train", `{"categories":["`+CategoryIdAttributionParent+`"]}`)
+		assert.Equal(t, http.StatusAccepted, resp.Code)
+
+		var job Job
+		json.Unmarshal(resp.Body.Bytes(), &job)
+		assert.Equal(t, TRAIN_MODEL, job.Kind)
+		assert.Equal(t, "/admin/jobs/"+job.Id, resp.Header().Get("Location"))
+
+		jobs.Wait(job.Id)
+
+		resp = request("GET", "/admin/jobs/"+job.Id, "")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		json.Unmarshal(resp.Body.Bytes(), &job)
+		assert.Equal(t, JOB_SUCCEEDED, job.Status)
+	}
+
+	t.Log("Given a malformed body or an unknown job, it returns 400 and 404.", checkMark)
+	assert.Equal(t, http.StatusBadRequest, request("POST", "/admin/jobs/train", "{").Code)
+	assert.Equal(t, http.StatusNotFound, request("GET", "/admin/jobs/unknown", "").Code)
+}
+
+func TestJobsCtrl_JobEvents(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := NewSuggester(config)
+	writeAttributionTestDataSet(s, CategoryIdAttributionParent, nil,
+		meli.SearchItem{Id: "MLA1", Price: 10, CategoryId: CategoryIdAttributionParent})
+	s.Train()
+
+	jobs := NewJobs(s)
+	jobsCtrl := NewJobsCtrl(jobs)
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/admin/jobs/:jobId/events", jobsCtrl.JobEvents)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	release := make(chan bool)
+	job, _ := jobs.start(FETCH_DATA_SET, func() error {
+		<-release
+		jobs.Progress(ProgressEvent{Type: EVENT_CATEGORY_FINISHED, Op: FETCH_DATA_SET, CategoryId: CategoryIdAttributionParent, Done: 1, Total: 1})
+		return nil
+	})
+
+	t.Log("Given a running job, its events are streamed until it finishes.", checkMark)
+	{
+		resp, err := http.Get(server.URL + "/admin/jobs/" + job.Id + "/events")
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+
+		close(release)
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		events := string(body)
+
+		assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"))
+		assert.Contains(t, events, "event:progress")
+		assert.Contains(t, events, `"type":"category_finished"`)
+		assert.Contains(t, events, `"status":"succeeded"`)
+		assert.Contains(t, events, `"progress":{"done":1,"total":1}`)
+	}
+
+	t.Log("Given a finished job, the stream ends after the job.", checkMark)
+	{
+		resp, err := http.Get(server.URL + "/admin/jobs/" + job.Id + "/events")
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.NotContains(t, string(body), "event:progress")
+		assert.Contains(t, string(body), `"status":"succeeded"`)
+	}
+}
+
+func TestJobsCtrl_JobEventsWriteTimeout(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	config.Server.WriteTimeoutMs = 100
+
+	s := NewSuggester(config)
+	writeAttributionTestDataSet(s, CategoryIdAttributionParent, nil,
+		meli.SearchItem{Id: "MLA1", Price: 10, CategoryId: CategoryIdAttributionParent})
+	s.Train()
+
+	jobs := NewJobs(s)
+	jobsCtrl := NewJobsCtrl(jobs)
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/admin/jobs/:jobId/events", jobsCtrl.JobEvents)
+
+	srv, _ := NewServer(s)
+	srv.handler = router
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go srv.Serve(ctx, listener)
+
+	release := make(chan bool)
+	job, _ := jobs.start(FETCH_DATA_SET, func() error {
+		<-release
+		for done := 1; done <= 3; done++ {
+			time.Sleep(config.Server.WriteTimeout())
+			jobs.Progress(ProgressEvent{Type: EVENT_CATEGORY_FINISHED, Op: FETCH_DATA_SET, Done: done, Total: 3})
+		}
+		return nil
+	})
+
+	t.Log("Given a job running longer than the write timeout, its events are streamed until it finishes.", checkMark)
+	{
+		resp, err := http.Get("http://" + listener.Addr().String() + "/admin/jobs/" + job.Id + "/events")
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+
+		close(release)
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		events := string(body)
+
+		assert.Equal(t, 3, strings.Count(events, "event:progress"))
+		assert.Contains(t, events, `"status":"succeeded"`)
+	}
+}
@@ -0,0 +1,40 @@
+package suggester
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	REQUEST_ID_HEADER string = "X-Request-Id"
+	// REQUEST_ID_KEY is the key of the request id in the gin context.
+	REQUEST_ID_KEY string = "requestId"
+	// API_ERRORS_DOCUMENTATION_URL is followed by the error code in ApiErr.
+	API_ERRORS_DOCUMENTATION_URL = "https://github.com/jesusfar/meli.price.suggester#"
+)
+
+// RequestId identifies every request by the X-Request-Id header, generated
+// when the client does not send it, and echoes it in the response.
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(REQUEST_ID_HEADER)
+
+		if requestId == "" {
+			requestId = newId()
+		}
+
+		c.Set(REQUEST_ID_KEY, requestId)
+		c.Header(REQUEST_ID_HEADER, requestId)
+
+		c.Next()
+	}
+}
+
+// newId returns a random id of 16 hex digits.
+func newId() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
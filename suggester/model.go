@@ -0,0 +1,158 @@
+package suggester
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ReadModelFile reads a trained model from path.
+func ReadModelFile(path string) (map[string]CategoryPriceTrained, error) {
+	modelFile, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeModel(modelFile)
+}
+
+// DecodeModel decodes a trained model. Every format written by Train must be
+// decodable here so LoadDataTrained and diff keep working between versions.
+func DecodeModel(data []byte) (map[string]CategoryPriceTrained, error) {
+	var dataTrained map[string]CategoryPriceTrained
+
+	err := json.Unmarshal(data, &dataTrained)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dataTrained, nil
+}
+
+// ModelInfo describes the model in memory.
+type ModelInfo struct {
+	// Version changes whenever the trained statistics change.
+	Version    string    `json:"version"`
+	TrainedAt  time.Time `json:"trained_at"`
+	Categories int       `json:"categories"`
+	Sites      []string  `json:"sites"`
+	// Items are the items trained, once whatever categories they are trained into.
+	Items    int           `json:"items"`
+	Coverage ModelCoverage `json:"coverage"`
+}
+
+// ModelCoverage is the share of the total items of the categories fetched
+// that the latest snapshots trained into the model sampled.
+type ModelCoverage struct {
+	Folders    int     `json:"folders"`
+	Items      int     `json:"items"`
+	TotalItems int     `json:"total_items"`
+	Ratio      float64 `json:"ratio"`
+}
+
+// Version returns the version of the model, a hash of its statistics.
+func (d *DataTrained) Version() string {
+	return d.version
+}
+
+// TrainedAt returns when the model was trained.
+func (d *DataTrained) TrainedAt() time.Time {
+	return d.trainedAt
+}
+
+// Len returns the number of categories of the model.
+func (d *DataTrained) Len() int {
+	d.RLock()
+	defer d.RUnlock()
+
+	return len(d.data)
+}
+
+// modelVersion hashes a model. Maps are encoded with sorted keys, so the same
+// statistics always have the same version.
+func modelVersion(data map[string]CategoryPriceTrained) string {
+	dataJson, _ := json.Marshal(data)
+	sum := sha256.Sum256(dataJson)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// siteOf returns the site prefix of a category id, MLA for MLA1051.
+func siteOf(categoryId string) string {
+	end := strings.IndexFunc(categoryId, unicode.IsDigit)
+
+	if end < 0 {
+		return categoryId
+	}
+
+	return categoryId[:end]
+}
+
+// modelCoverage adds up the snapshot info of the latest snapshot of the folders of manifest.
+func (s *Suggester) modelCoverage(manifest TrainManifest) ModelCoverage {
+	var coverage ModelCoverage
+
+	for folder := range manifest.Folders {
+		coverage.Folders++
+
+		snapshotDate, ok, err := latestSnapshotDate(s.storage, folder)
+		if err != nil || !ok {
+			continue
+		}
+
+		info, err := s.storage.ReadSnapshotInfo(folder, snapshotDate)
+		if err != nil {
+			continue
+		}
+
+		coverage.Items += info.Items
+		coverage.TotalItems += info.TotalItems
+	}
+
+	if coverage.TotalItems > 0 {
+		coverage.Ratio = float64(coverage.Items) / float64(coverage.TotalItems)
+	}
+
+	return coverage
+}
+
+// ModelInfo describes the model in memory, see GetInMemoryDataTrained.
+func (s *Suggester) ModelInfo() (ModelInfo, error) {
+	dataTrained := s.GetInMemoryDataTrained()
+
+	if dataTrained == nil {
+		return ModelInfo{}, ErrModelNotLoaded
+	}
+
+	dataTrained.RLock()
+	defer dataTrained.RUnlock()
+
+	info := ModelInfo{
+		Version:    dataTrained.version,
+		TrainedAt:  dataTrained.trainedAt,
+		Categories: len(dataTrained.data),
+		Sites:      []string{},
+		Items:      dataTrained.items,
+		Coverage:   dataTrained.coverage,
+	}
+
+	sites := make(map[string]bool)
+
+	for categoryId := range dataTrained.data {
+		if site := siteOf(categoryId); !sites[site] {
+			sites[site] = true
+			info.Sites = append(info.Sites, site)
+		}
+	}
+
+	sort.Strings(info.Sites)
+
+	return info, nil
+}
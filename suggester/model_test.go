@@ -0,0 +1,68 @@
+package suggester
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadModelFile(t *testing.T) {
+	modelFile, _ := ioutil.TempFile("", "datatrained")
+	defer os.Remove(modelFile.Name())
+
+	modelFile.WriteString(`{"MLA1051":{"Max":100,"Suggested":90,"Min":60,"Sum":180,"Total":2}}`)
+	modelFile.Close()
+
+	model, err := ReadModelFile(modelFile.Name())
+
+	t.Log("Given a CategoryPriceTrained model file, ReadModelFile decodes it.", checkMark)
+	assert.Nil(t, err)
+	assert.Equal(t, 90.0, model["MLA1051"].Suggested)
+}
+
+func TestDecodeModelInvalid(t *testing.T) {
+	_, err := DecodeModel([]byte("not a model"))
+
+	t.Log("Given an invalid model, DecodeModel returns error.", checkMark)
+	assert.NotNil(t, err)
+}
+
+func TestSuggester_ModelInfo(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	trainedAt := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSuggester(config, WithClock(func() time.Time { return trainedAt }))
+
+	_, err := s.ModelInfo()
+
+	t.Log("Given no model loaded, ModelInfo returns error.", checkMark)
+	assert.NotNil(t, err)
+
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdTest, 10, 20, 30)
+	s.storage.WriteSnapshotInfo(CategoryIdTest, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), SnapshotInfo{Items: 3, TotalItems: 30})
+	s.Train()
+
+	info, err := s.ModelInfo()
+
+	t.Log("Given a trained model, ModelInfo describes it.", checkMark)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, info.Categories)
+	assert.Equal(t, []string{"MLA"}, info.Sites)
+	assert.Equal(t, 3, info.Items)
+	assert.Equal(t, trainedAt, info.TrainedAt)
+	assert.Equal(t, ModelCoverage{Folders: 1, Items: 3, TotalItems: 30, Ratio: 0.1}, info.Coverage)
+	assert.NotEmpty(t, info.Version)
+
+	version := info.Version
+
+	loaded := NewSuggester(config)
+	loaded.LoadDataTrained()
+	info, _ = loaded.ModelInfo()
+
+	t.Log("Given the model loaded from storage, it has the same version and training date.", checkMark)
+	assert.Equal(t, version, info.Version)
+	assert.Equal(t, trainedAt, info.TrainedAt.UTC())
+}
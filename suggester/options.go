@@ -0,0 +1,102 @@
+package suggester
+
+import (
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"math/rand"
+	"time"
+)
+
+// Option sets a dependency of the Suggester in NewSuggester.
+type Option func(s *Suggester)
+
+// Logger is the logger used by the Suggester, util.Logger by default.
+type Logger interface {
+	Info(v ...interface{})
+	Warning(v ...interface{})
+	Debug(v ...interface{})
+}
+
+// WithMeliClient sets the client items are fetched with, instead of a
+// meli.MeliHttpClient to the configured endpoint.
+func WithMeliClient(meliClient meli.MeliClient) Option {
+	return func(s *Suggester) {
+		s.meliClient = meliClient
+	}
+}
+
+// WithLogger sets the logger of the suggester, instead of util.NewLogger().
+func WithLogger(logger Logger) Option {
+	return func(s *Suggester) {
+		s.logger = logger
+	}
+}
+
+// WithStorage sets the storage of data set and trained files, instead of the
+// file storage in the configured paths. See NewStorage.
+func WithStorage(storage Storage) Option {
+	return func(s *Suggester) {
+		s.storage = storage
+	}
+}
+
+// WithClock sets the function returning the current time, used to date
+// snapshots, trainings and adjustments.
+func WithClock(now func() time.Time) Option {
+	return func(s *Suggester) {
+		s.now = now
+	}
+}
+
+// WithRand sets the source of the random start offset of the systematic random sampling.
+func WithRand(random *rand.Rand) Option {
+	return func(s *Suggester) {
+		s.rand = random
+	}
+}
+
+// WithObserver sets the observer of the progress of fetch and train. See AddObserver.
+func WithObserver(observer Observer) Option {
+	return func(s *Suggester) {
+		s.AddObserver(observer)
+	}
+}
+
+// LoadModel loads the data trained, and the price history and price index if
+// they exist, from storage and keep them in memory.
+// They replace the model in memory at once, so it is never suggested with
+// the data trained of a load and the price history of another.
+func (s *Suggester) LoadModel() error {
+	dataTrained, err := s.readDataTrained()
+
+	if err != nil {
+		return err
+	}
+
+	priceHistory, err := s.readPriceHistory()
+
+	if err != nil {
+		s.logger.Debug("[LoadModel] Price history not loaded, trend adjustments are not available.")
+	}
+
+	priceIndex, err := s.readPriceIndex()
+
+	if err != nil {
+		s.logger.Debug("[LoadModel] Price index not loaded, index adjustments are not available.")
+	}
+
+	historyVersion := priceHistoryVersion(priceHistory)
+
+	s.updateModel(func(m *model) {
+		*m = model{
+			dataTrained:         dataTrained,
+			priceHistory:        priceHistory,
+			priceHistoryVersion: historyVersion,
+			priceHistoryAt:      dataTrained.TrainedAt(),
+			priceIndex:          priceIndex,
+		}
+	})
+
+	s.logger.Info("[LoadDataTrained][Notice]  Data trained load [OK]")
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package suggester
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Types of the progress events of fetch and train.
+const (
+	EVENT_CATEGORY_STARTED  string = "category_started"
+	EVENT_CATEGORY_FINISHED string = "category_finished"
+	EVENT_PAGE_FETCHED      string = "page_fetched"
+	EVENT_ITEMS_READ        string = "items_read"
+)
+
+// ProgressEvent is a step of a fetch or train of categories.
+type ProgressEvent struct {
+	Type       string `json:"type"`
+	Op         string `json:"op"`
+	CategoryId string `json:"category_id"`
+	// Done and Total are the categories finished and to fetch or train.
+	Done  int `json:"done"`
+	Total int `json:"total"`
+	// Pages are the pages of the category fetched so far.
+	Pages int `json:"pages,omitempty"`
+	// Items are the items of the category fetched or read so far.
+	Items int    `json:"items,omitempty"`
+	Error string `json:"error,omitempty"`
+	// EtaSeconds is the time left at the pace of the categories finished, zero until one is.
+	EtaSeconds float64   `json:"eta_seconds"`
+	Time       time.Time `json:"time"`
+}
+
+// Observer receives the progress events of fetch and train. Training reads
+// categories concurrently, so Progress has to be safe to call from several
+// goroutines and should not block.
+type Observer interface {
+	Progress(event ProgressEvent)
+}
+
+// ObserverFunc is a function receiving progress events as an Observer.
+type ObserverFunc func(event ProgressEvent)
+
+func (f ObserverFunc) Progress(event ProgressEvent) {
+	f(event)
+}
+
+// observers sends the events to each of them.
+type observers []Observer
+
+func (o observers) Progress(event ProgressEvent) {
+	for _, observer := range o {
+		observer.Progress(event)
+	}
+}
+
+// AddObserver adds an observer of the progress of fetch and train. It is not
+// safe to call while fetching or training.
+func (s *Suggester) AddObserver(observer Observer) {
+	if s.observer == nil {
+		s.observer = observer
+		return
+	}
+
+	s.observer = observers{s.observer, observer}
+}
+
+// progress tracks the categories finished of a fetch or train and sends its
+// events to the observer. A nil progress sends nothing.
+type progress struct {
+	observer  Observer
+	op        string
+	total     int
+	done      int32
+	startedAt time.Time
+	now       func() time.Time
+}
+
+// newProgress returns the progress of op over total categories, nil without observer.
+func (s *Suggester) newProgress(op string, total int) *progress {
+	if s.observer == nil {
+		return nil
+	}
+
+	return &progress{observer: s.observer, op: op, total: total, startedAt: s.now(), now: s.now}
+}
+
+func (p *progress) send(event ProgressEvent) {
+	event.Op = p.op
+	event.Total = p.total
+	event.Time = p.now()
+
+	if event.Done == 0 {
+		event.Done = int(atomic.LoadInt32(&p.done))
+	}
+
+	if event.Done > 0 {
+		elapsed := event.Time.Sub(p.startedAt).Seconds()
+		event.EtaSeconds = elapsed / float64(event.Done) * float64(p.total-event.Done)
+	}
+
+	p.observer.Progress(event)
+}
+
+func (p *progress) categoryStarted(categoryId string) {
+	if p != nil {
+		p.send(ProgressEvent{Type: EVENT_CATEGORY_STARTED, CategoryId: categoryId})
+	}
+}
+
+func (p *progress) categoryFinished(categoryId string, err string) {
+	if p != nil {
+		done := int(atomic.AddInt32(&p.done, 1))
+		p.send(ProgressEvent{Type: EVENT_CATEGORY_FINISHED, CategoryId: categoryId, Done: done, Error: err})
+	}
+}
+
+func (p *progress) pageFetched(categoryId string, pages int, items int) {
+	if p != nil {
+		p.send(ProgressEvent{Type: EVENT_PAGE_FETCHED, CategoryId: categoryId, Pages: pages, Items: items})
+	}
+}
+
+func (p *progress) itemsRead(categoryId string, items int) {
+	if p != nil {
+		p.send(ProgressEvent{Type: EVENT_ITEMS_READ, CategoryId: categoryId, Items: items})
+	}
+}
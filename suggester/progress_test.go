@@ -0,0 +1,105 @@
+package suggester
+
+import (
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/jesusfar/meli.price.suggester/mock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// progressEventsTest collects the progress events observed.
+type progressEventsTest struct {
+	sync.Mutex
+	events []ProgressEvent
+}
+
+func (p *progressEventsTest) Progress(event ProgressEvent) {
+	p.Lock()
+	defer p.Unlock()
+	p.events = append(p.events, event)
+}
+
+func (p *progressEventsTest) ofType(eventType string) []ProgressEvent {
+	var events []ProgressEvent
+	for _, event := range p.events {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestSuggester_ProgressTrain(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	now := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	observer := &progressEventsTest{}
+
+	// Every reading of the clock is a second later
+	var clock sync.Mutex
+	s := NewSuggester(config, WithObserver(observer), WithClock(func() time.Time {
+		clock.Lock()
+		defer clock.Unlock()
+		now = now.Add(time.Second)
+		return now
+	}))
+
+	writeAttributionTestDataSet(s, CategoryIdAttributionParent, nil,
+		meli.SearchItem{Id: "MLA1", Price: 10, CategoryId: CategoryIdAttributionParent},
+		meli.SearchItem{Id: "MLA2", Price: 30, CategoryId: CategoryIdAttributionParent})
+	writeAttributionTestDataSet(s, CategoryIdAttributionSibling, nil,
+		meli.SearchItem{Id: "MLA3", Price: 50, CategoryId: CategoryIdAttributionSibling})
+
+	s.Train()
+
+	t.Log("Given a train, each category is started, read and finished.", checkMark)
+	{
+		assert.Len(t, observer.ofType(EVENT_CATEGORY_STARTED), 2)
+		assert.Len(t, observer.ofType(EVENT_ITEMS_READ), 2)
+
+		finished := observer.ofType(EVENT_CATEGORY_FINISHED)
+		if assert.Len(t, finished, 2) {
+			assert.Equal(t, TRAIN_MODEL, finished[0].Op)
+			assert.Equal(t, 2, finished[0].Total)
+			assert.True(t, finished[0].EtaSeconds > 0)
+			assert.Equal(t, 0.0, finished[1].EtaSeconds)
+		}
+	}
+}
+
+func TestSuggester_ProgressFetch(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	mockServer := httptest.NewServer(http.HandlerFunc(mock.SearchItemsMock))
+	defer mockServer.Close()
+
+	config.Endpoint = mockServer.URL
+
+	observer := &progressEventsTest{}
+	s := NewSuggester(config, WithObserver(observer))
+
+	s.FetchItemsBySystematicRandomSampling(meli.SITE_MLA, CategoryIdTest)
+
+	t.Log("Given a fetch, the pages fetched are reported between the category started and finished.", checkMark)
+	{
+		events := observer.events
+		if assert.True(t, len(events) > 2) {
+			assert.Equal(t, EVENT_CATEGORY_STARTED, events[0].Type)
+			assert.Equal(t, EVENT_PAGE_FETCHED, events[1].Type)
+			assert.Equal(t, 1, events[1].Pages)
+			assert.Equal(t, FETCH_DATA_SET, events[1].Op)
+
+			last := events[len(events)-1]
+			assert.Equal(t, EVENT_CATEGORY_FINISHED, last.Type)
+			assert.Equal(t, CategoryIdTest, last.CategoryId)
+			assert.Equal(t, 1, last.Done)
+			assert.Equal(t, 1, last.Total)
+		}
+	}
+}
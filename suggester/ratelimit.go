@@ -0,0 +1,117 @@
+package suggester
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxIdleBuckets is how many buckets are kept before the full ones, of the
+// clients that stopped calling, are dropped.
+const maxIdleBuckets = 10000
+
+// tokenBucket holds up to burst tokens, refilled at rate per second.
+type tokenBucket struct {
+	tokens float64
+	rate   float64
+	burst  float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per client.
+type rateLimiter struct {
+	sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+func newRateLimiter(now func() time.Time) *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket), now: now}
+}
+
+// allow takes a token of the bucket of client, or returns how long until there
+// is one.
+func (l *rateLimiter) allow(client string, rate float64, burst int) (bool, time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+
+	bucket, ok := l.buckets[client]
+
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.dropFull(now)
+		}
+		bucket = &tokenBucket{tokens: float64(burst), last: now}
+		l.buckets[client] = bucket
+	}
+
+	bucket.rate = rate
+	bucket.burst = float64(burst)
+	bucket.refill(now)
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// dropFull drops the buckets that refilled, as new ones would be the same.
+func (l *rateLimiter) dropFull(now time.Time) {
+	for client, bucket := range l.buckets {
+		if bucket.refill(now); bucket.tokens >= bucket.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// RateLimit rejects with 429 and Retry-After the requests over the rate limit
+// of their API key, or of their client IP when the API is open.
+func RateLimit(config ServerConfig) gin.HandlerFunc {
+	return rateLimit(config, newRateLimiter(time.Now))
+}
+
+func rateLimit(config ServerConfig, limiter *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client, rate, burst := "ip:"+c.ClientIP(), config.RateLimit, config.RateBurst
+
+		if apiKey, ok := requestApiKey(c); ok {
+			client = "key:" + apiKey.Name
+
+			if apiKey.RateLimit > 0 {
+				rate = apiKey.RateLimit
+			}
+			if apiKey.RateBurst > 0 {
+				burst = apiKey.RateBurst
+			}
+		}
+
+		if rate <= 0 {
+			c.Next()
+			return
+		}
+
+		if burst < 1 {
+			burst = 1
+		}
+
+		if ok, retryAfter := limiter.allow(client, rate, burst); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			abortWithError(c, newSuggesterError(ERR_RATE_LIMITED, fmt.Sprintf("Rate limit of %g requests per second exceeded.", rate)))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package suggester
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(func() time.Time { return now })
+
+	t.Log("Given a burst of 2, the third request at once waits for a token.", checkMark)
+	{
+		ok, _ := limiter.allow("a", 0.5, 2)
+		assert.True(t, ok)
+		ok, _ = limiter.allow("a", 0.5, 2)
+		assert.True(t, ok)
+
+		ok, retryAfter := limiter.allow("a", 0.5, 2)
+		assert.False(t, ok)
+		assert.Equal(t, 2*time.Second, retryAfter)
+	}
+
+	t.Log("Given other client, it has its own bucket.", checkMark)
+	{
+		ok, _ := limiter.allow("b", 0.5, 2)
+		assert.True(t, ok)
+	}
+
+	t.Log("Given the bucket refilled, the request is allowed.", checkMark)
+	{
+		now = now.Add(2 * time.Second)
+		ok, _ := limiter.allow("a", 0.5, 2)
+		assert.True(t, ok)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(func() time.Time { return now })
+	keys := ApiKeys{Keys: []ApiKey{
+		{Name: "reader", Key: "reader-key", Scopes: []string{SCOPE_READ}},
+		{Name: "batch", Key: "batch-key", Scopes: []string{SCOPE_READ}, RateBurst: 3},
+	}}
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/read", Authenticate(keys), rateLimit(ServerConfig{RateLimit: 0.1, RateBurst: 1}, limiter),
+		func(c *gin.Context) { c.String(http.StatusOK, "read") })
+
+	get := func(key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/read", nil)
+		req.Header.Set(API_KEY_HEADER, key)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Log("Given a key over its rate, it returns 429 with Retry-After.", checkMark)
+	{
+		assert.Equal(t, http.StatusOK, get("reader-key").Code)
+
+		resp := get("reader-key")
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "10", resp.Header().Get("Retry-After"))
+	}
+
+	t.Log("Given a key with its own burst, it is limited by it.", checkMark)
+	{
+		for request := 0; request < 3; request++ {
+			assert.Equal(t, http.StatusOK, get("batch-key").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, get("batch-key").Code)
+	}
+}
@@ -0,0 +1,182 @@
+package suggester
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Server is the http service of the suggester.
+type Server struct {
+	config  ServerConfig
+	handler http.Handler
+	logger  Logger
+}
+
+// NewServer returns the http service of s, configured by its ServerConfig.
+func NewServer(s *Suggester) (*Server, error) {
+	router, err := NewRouter(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		config:  s.config.Server,
+		handler: router,
+		logger:  s.logger,
+	}, nil
+}
+
+// NewRouter returns the routes of the http API of s. The probes are open and
+// the rest require an API key with read scope when an API keys file is
+// configured. The admin jobs are only served then, to keys with admin scope.
+func NewRouter(s *Suggester) (*gin.Engine, error) {
+	var keys ApiKeys
+
+	if s.config.Server.ApiKeysFile != "" {
+		var err error
+		if keys, err = LoadApiKeys(s.config.Server.ApiKeysFile); err != nil {
+			return nil, err
+		}
+	}
+
+	ctrl := NewSuggesterCtrl(s)
+
+	r := gin.Default()
+	r.Use(RequestId())
+
+	r.GET("/healthz", ctrl.Health)
+	r.GET("/readyz", ctrl.Ready)
+
+	rateLimit := RateLimit(s.config.Server)
+
+	read := r.Group("/", Authenticate(keys), rateLimit, RequireScope(SCOPE_READ))
+
+	read.GET("/model", ctrl.ModelInfo)
+
+	read.GET("/categories", ctrl.ListCategories)
+	read.GET("/categories/:categoryId", ctrl.CategoryById)
+	read.GET("/categories/:categoryId/prices", ctrl.SuggestPriceByCategory)
+	read.GET("/categories/:categoryId/prices/history", ctrl.PriceHistoryByCategory)
+
+	// Jobs write the data set and the model, they are not served by an open API
+	if len(keys.Keys) > 0 {
+		jobsCtrl := NewJobsCtrl(NewJobs(s))
+
+		admin := r.Group("/admin", Authenticate(keys), rateLimit, RequireScope(SCOPE_ADMIN))
+
+		admin.POST("/jobs/fetch", jobsCtrl.StartFetch)
+		admin.POST("/jobs/train", jobsCtrl.StartTrain)
+		admin.GET("/jobs/:jobId", jobsCtrl.JobById)
+		admin.GET("/jobs/:jobId/events", jobsCtrl.JobEvents)
+	}
+
+	return r, nil
+}
+
+// responseWriterKey is the key of the http.ResponseWriter of the server in
+// the request context.
+type responseWriterKey struct{}
+
+// withResponseWriter keeps the http.ResponseWriter of the server in the
+// request context. The gin one can't always be unwrapped to it, which the
+// write deadline of a response is set on.
+func withResponseWriter(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), responseWriterKey{}, w)))
+	})
+}
+
+// serverResponseWriter returns the http.ResponseWriter of the server of the
+// request, its gin one when it is not served by Serve.
+func serverResponseWriter(c *gin.Context) http.ResponseWriter {
+	if w, ok := c.Request.Context().Value(responseWriterKey{}).(http.ResponseWriter); ok {
+		return w
+	}
+
+	return c.Writer
+}
+
+// ShutdownContext returns a context done when the process receives SIGTERM or
+// SIGINT, so the server is shut down instead of the process killed.
+func ShutdownContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+
+	return ctx, cancel
+}
+
+// ListenAndServe listens on the configured address and serves until ctx is done. See Serve.
+func (srv *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", srv.config.Address)
+
+	if err != nil {
+		return err
+	}
+
+	return srv.Serve(ctx, listener)
+}
+
+// Serve serves the API on listener, with TLS when a cert and key are
+// configured, until ctx is done. Then it stops accepting connections and waits
+// for the in-flight requests up to the shutdown grace period.
+func (srv *Server) Serve(ctx context.Context, listener net.Listener) error {
+	httpServer := &http.Server{
+		Handler:      withResponseWriter(srv.handler),
+		ReadTimeout:  srv.config.ReadTimeout(),
+		WriteTimeout: srv.config.WriteTimeout(),
+		IdleTimeout:  srv.config.IdleTimeout(),
+	}
+
+	served := make(chan error, 1)
+
+	go func() {
+		srv.logger.Info(fmt.Sprintf("[Serve] Listening on: %s TLS: %t", listener.Addr(), srv.config.TLS()))
+
+		if srv.config.TLS() {
+			served <- httpServer.ServeTLS(listener, srv.config.CertFile, srv.config.KeyFile)
+		} else {
+			served <- httpServer.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	srv.logger.Info(fmt.Sprintf("[Serve] Shutting down, waiting %s for in-flight requests.", srv.config.ShutdownGrace()))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), srv.config.ShutdownGrace())
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		// The requests still in flight after the grace period are cut off
+		httpServer.Close()
+		return err
+	}
+
+	if err := <-served; err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package suggester
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServer_GracefulShutdown(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	config.Server.Address = "127.0.0.1:0"
+
+	started := make(chan bool)
+	release := make(chan bool)
+
+	srv, _ := NewServer(NewSuggester(config))
+	srv.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("served"))
+	})
+
+	listener, err := net.Listen("tcp", config.Server.Address)
+	assert.Nil(t, err)
+	address := listener.Addr().String()
+
+	ctx, cancel := ShutdownContext()
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, listener)
+	}()
+
+	response := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + address)
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+
+	<-started
+
+	process, _ := os.FindProcess(os.Getpid())
+	process.Signal(syscall.SIGTERM)
+
+	t.Log("Given SIGTERM, the server stops accepting connections.", checkMark)
+	{
+		refused := false
+		for attempt := 0; attempt < 100 && !refused; attempt++ {
+			conn, err := net.Dial("tcp", address)
+			if err != nil {
+				refused = true
+			} else {
+				conn.Close()
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		assert.True(t, refused)
+	}
+
+	close(release)
+
+	t.Log("Given SIGTERM, the in-flight request is served before Serve returns.", checkMark)
+	assert.Equal(t, "served", <-response)
+	assert.Nil(t, <-served)
+}
+
+func TestServer_ShutdownGrace(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	config.Server.ShutdownGraceMs = 10
+
+	started := make(chan bool)
+	release := make(chan bool)
+	defer close(release)
+
+	srv, _ := NewServer(NewSuggester(config))
+	srv.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+
+	ctx, cancel := ShutdownContext()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, listener)
+	}()
+
+	go http.Get("http://" + listener.Addr().String())
+
+	<-started
+	cancel()
+
+	t.Log("Given a request longer than the shutdown grace, Serve returns error.", checkMark)
+	assert.NotNil(t, <-served)
+}
@@ -0,0 +1,153 @@
+package suggester
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"time"
+)
+
+const (
+	STORAGE_FILE        string = "file"
+	STORAGE_BOLT        string = "bolt"
+	BOLT_FILE_PATH             = "./suggester.db"
+	DATA_TRAINED_FILE          = "datatrained.json"
+	PRICE_HISTORY_FILE         = "pricehistory.json"
+	TRAIN_MANIFEST_FILE        = "manifest.json"
+	TRAIN_REPORT_FILE          = "trainreport.json"
+)
+
+// Storage keeps the items of the data set snapshots and the files produced by training.
+type Storage interface {
+	// DataSetCategories returns the categories with a data set.
+	DataSetCategories() ([]string, error)
+
+	// Snapshots returns the dates of the snapshots of a category, oldest first.
+	Snapshots(categoryId string) ([]time.Time, error)
+
+	// NewDataSetWriter returns a writer that replaces the snapshot of a category at date once committed.
+	NewDataSetWriter(categoryId string, date time.Time, format string) (SnapshotWriter, error)
+
+	// ReadSnapshot calls read with each chunk of items of a snapshot and the source it was read from.
+	ReadSnapshot(categoryId string, date time.Time, read func(source string, items []meli.SearchItem, err error)) error
+
+	// SnapshotHash returns a hash that changes when the items of a snapshot change.
+	SnapshotHash(categoryId string, date time.Time) (string, error)
+
+	// WriteSnapshotInfo saves how a snapshot was fetched.
+	WriteSnapshotInfo(categoryId string, date time.Time, info SnapshotInfo) error
+
+	// ReadSnapshotInfo returns an error satisfying os.IsNotExist for snapshots fetched without info.
+	ReadSnapshotInfo(categoryId string, date time.Time) (SnapshotInfo, error)
+
+	// ReadTrained reads a file produced by training, like DATA_TRAINED_FILE.
+	ReadTrained(name string) ([]byte, error)
+
+	// WriteTrained saves a file produced by training.
+	WriteTrained(name string, data []byte) error
+
+	// Clean removes the data set and the trained files.
+	Clean() error
+
+	Close() error
+}
+
+// SnapshotWriter writes the pages of a snapshot aside. Commit replaces the
+// snapshot with them and its info, and Abort discards them, so a failed fetch
+// leaves the snapshot fetched before on the same day untouched.
+type SnapshotWriter interface {
+	WritePage(items []meli.SearchItem, offset int) error
+	Commit(info SnapshotInfo) error
+	Abort() error
+}
+
+// SnapshotInfo describes the fetch of a category snapshot.
+type SnapshotInfo struct {
+	Site      string         `json:"site"`
+	FetchedAt time.Time      `json:"fetched_at"`
+	Format    string         `json:"format"`
+	Sampling  SamplingConfig `json:"sampling"`
+	// TotalItems is the Paging.Total of the category when it was fetched.
+	TotalItems    int `json:"total_items"`
+	SampleSize    int `json:"sample_size"`
+	Proportion    int `json:"proportion"`
+	InitialOffset int `json:"initial_offset"`
+	Pages         int `json:"pages"`
+	EmptyPages    int `json:"empty_pages"`
+	// Items is the number of items saved, after deduplication if enabled.
+	Items int `json:"items"`
+	// CategoryPath is the ids of the categories from the root to the category fetched.
+	CategoryPath []string `json:"category_path,omitempty"`
+	// CategoryNames are the names of the categories of CategoryPath by id.
+	CategoryNames map[string]string `json:"category_names,omitempty"`
+}
+
+// ItemQuery selects the items of a category snapshot within a price range.
+type ItemQuery struct {
+	CategoryId string
+	// Snapshot is the date of the snapshot, the latest one if zero.
+	Snapshot time.Time
+	MinPrice float64
+	// MaxPrice is the upper bound of the price, without bound if zero.
+	MaxPrice float64
+}
+
+// NewStorage returns the storage set in config: file (default) or bolt.
+func NewStorage(config Config) (Storage, error) {
+	switch config.Storage {
+	case STORAGE_FILE, "":
+		return NewFileStorage(config.DataSetPath, config.DataTrainedPath), nil
+
+	case STORAGE_BOLT:
+		return NewBoltStorage(config.BoltFile)
+
+	default:
+		return nil, errors.New(fmt.Sprintf("Storage: %s is not supported.", config.Storage))
+	}
+}
+
+// QueryItems returns the items of a storage matching query.
+func QueryItems(storage Storage, query ItemQuery) ([]meli.SearchItem, error) {
+	var items []meli.SearchItem
+
+	snapshot := query.Snapshot
+
+	if snapshot.IsZero() {
+		dates, err := storage.Snapshots(query.CategoryId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(dates) == 0 {
+			return items, nil
+		}
+
+		snapshot = dates[len(dates)-1]
+	}
+
+	err := storage.ReadSnapshot(query.CategoryId, snapshot, func(source string, chunk []meli.SearchItem, err error) {
+		for _, item := range chunk {
+			if item.Price < query.MinPrice {
+				continue
+			}
+			if query.MaxPrice != 0 && item.Price > query.MaxPrice {
+				continue
+			}
+			items = append(items, item)
+		}
+	})
+
+	return items, err
+}
+
+// latestSnapshotDate returns the date of the most recent snapshot of a category.
+func latestSnapshotDate(storage Storage, categoryId string) (time.Time, bool, error) {
+	dates, err := storage.Snapshots(categoryId)
+
+	if err != nil || len(dates) == 0 {
+		return time.Time{}, false, err
+	}
+
+	return dates[len(dates)-1], true, nil
+}
@@ -0,0 +1,388 @@
+package suggester
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"sort"
+	"time"
+)
+
+var (
+	boltDataSetsBucket  = []byte("datasets")
+	boltSnapshotsBucket = []byte("snapshots")
+	boltTrainedBucket   = []byte("trained")
+)
+
+// BoltStorage keeps the data set and the trained files in an embedded bbolt
+// database: datasets/<category>/<snapshot date>/<sequence> -> item JSON,
+// snapshots/<category>/<snapshot date> -> snapshot info JSON and
+// trained/<name> -> file content.
+type BoltStorage struct {
+	db *bolt.DB
+}
+
+// boltDataSetWriter writes the items of a snapshot in a staging bucket next to
+// it, in one transaction per page, copied in its place on Commit.
+type boltDataSetWriter struct {
+	db         *bolt.DB
+	categoryId string
+	snapshot   string
+	staging    string
+}
+
+// NewBoltStorage opens or creates the bbolt database at path.
+func NewBoltStorage(path string) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return createBoltBuckets(tx)
+	})
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStorage{db: db}, nil
+}
+
+func (b *BoltStorage) DataSetCategories() ([]string, error) {
+	var categories []string
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDataSetsBucket).ForEach(func(key []byte, value []byte) error {
+			if value == nil {
+				categories = append(categories, string(key))
+			}
+			return nil
+		})
+	})
+
+	return categories, err
+}
+
+func (b *BoltStorage) Snapshots(categoryId string) ([]time.Time, error) {
+	var dates []time.Time
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		category := tx.Bucket(boltDataSetsBucket).Bucket([]byte(categoryId))
+
+		if category == nil {
+			return errors.New(fmt.Sprintf("Dataset of category: %s not found.", categoryId))
+		}
+
+		return category.ForEach(func(key []byte, value []byte) error {
+			date, err := time.Parse(SNAPSHOT_DATE_LAYOUT, string(key))
+
+			if err == nil && value == nil {
+				dates = append(dates, date)
+			}
+			return nil
+		})
+	})
+
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	return dates, err
+}
+
+// NewDataSetWriter returns a writer of the snapshot of a category at date.
+// The database keeps its own format, so format is ignored.
+func (b *BoltStorage) NewDataSetWriter(categoryId string, date time.Time, format string) (SnapshotWriter, error) {
+	snapshot := date.Format(SNAPSHOT_DATE_LAYOUT)
+	staging := snapshot + ".fetching"
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		category, err := tx.Bucket(boltDataSetsBucket).CreateBucketIfNotExists([]byte(categoryId))
+
+		if err != nil {
+			return err
+		}
+
+		// Pages left by a fetch that did not finish are discarded
+		if category.Bucket([]byte(staging)) != nil {
+			if err := category.DeleteBucket([]byte(staging)); err != nil {
+				return err
+			}
+		}
+
+		_, err = category.CreateBucket([]byte(staging))
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltDataSetWriter{db: b.db, categoryId: categoryId, snapshot: snapshot, staging: staging}, nil
+}
+
+// ReadSnapshot calls read once with every item of the snapshot.
+func (b *BoltStorage) ReadSnapshot(categoryId string, date time.Time, read func(source string, items []meli.SearchItem, err error)) error {
+	var items []meli.SearchItem
+	var readErr error
+
+	source := categoryId + "/" + date.Format(SNAPSHOT_DATE_LAYOUT)
+
+	err := b.viewSnapshot(categoryId, date, func(snapshot *bolt.Bucket) error {
+		return snapshot.ForEach(func(key []byte, value []byte) error {
+			var item meli.SearchItem
+
+			if err := json.Unmarshal(value, &item); err != nil {
+				readErr = err
+				return nil
+			}
+
+			items = append(items, item)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	read(source, items, readErr)
+
+	return nil
+}
+
+func (b *BoltStorage) SnapshotHash(categoryId string, date time.Time) (string, error) {
+	hash := sha256.New()
+
+	err := b.viewSnapshot(categoryId, date, func(snapshot *bolt.Bucket) error {
+		return snapshot.ForEach(func(key []byte, value []byte) error {
+			hash.Write(value)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (b *BoltStorage) WriteSnapshotInfo(categoryId string, date time.Time, info SnapshotInfo) error {
+	infoJson, err := json.Marshal(info)
+
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSnapshotsBucket).Put(boltSnapshotInfoKey(categoryId, date.Format(SNAPSHOT_DATE_LAYOUT)), infoJson)
+	})
+}
+
+func (b *BoltStorage) ReadSnapshotInfo(categoryId string, date time.Time) (SnapshotInfo, error) {
+	var info SnapshotInfo
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		key := boltSnapshotInfoKey(categoryId, date.Format(SNAPSHOT_DATE_LAYOUT))
+		value := tx.Bucket(boltSnapshotsBucket).Get(key)
+
+		if value == nil {
+			return &os.PathError{Op: "read", Path: string(key), Err: os.ErrNotExist}
+		}
+
+		return json.Unmarshal(value, &info)
+	})
+
+	return info, err
+}
+
+// ReadTrained returns an error satisfying os.IsNotExist when name was never written.
+func (b *BoltStorage) ReadTrained(name string) ([]byte, error) {
+	var data []byte
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(boltTrainedBucket).Get([]byte(name))
+
+		if value == nil {
+			return &os.PathError{Op: "read", Path: name, Err: os.ErrNotExist}
+		}
+
+		// Values are only valid inside the transaction
+		data = append([]byte(nil), value...)
+		return nil
+	})
+
+	return data, err
+}
+
+func (b *BoltStorage) WriteTrained(name string, data []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltTrainedBucket).Put([]byte(name), data)
+	})
+}
+
+func (b *BoltStorage) Clean() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(boltDataSetsBucket); err != nil {
+			return err
+		}
+
+		if err := tx.DeleteBucket(boltSnapshotsBucket); err != nil {
+			return err
+		}
+
+		if err := tx.DeleteBucket(boltTrainedBucket); err != nil {
+			return err
+		}
+
+		return createBoltBuckets(tx)
+	})
+}
+
+func (b *BoltStorage) Close() error {
+	return b.db.Close()
+}
+
+func (b *BoltStorage) viewSnapshot(categoryId string, date time.Time, view func(snapshot *bolt.Bucket) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		category := tx.Bucket(boltDataSetsBucket).Bucket([]byte(categoryId))
+
+		var snapshot *bolt.Bucket
+		if category != nil {
+			snapshot = category.Bucket([]byte(date.Format(SNAPSHOT_DATE_LAYOUT)))
+		}
+
+		if snapshot == nil {
+			return errors.New(fmt.Sprintf("Snapshot: %s of category: %s not found.", date.Format(SNAPSHOT_DATE_LAYOUT), categoryId))
+		}
+
+		return view(snapshot)
+	})
+}
+
+func (w *boltDataSetWriter) WritePage(items []meli.SearchItem, offset int) error {
+	return w.db.Update(func(tx *bolt.Tx) error {
+		snapshot := tx.Bucket(boltDataSetsBucket).Bucket([]byte(w.categoryId)).Bucket([]byte(w.staging))
+
+		for _, item := range items {
+			itemJson, err := json.Marshal(item)
+
+			if err != nil {
+				return err
+			}
+
+			sequence, _ := snapshot.NextSequence()
+
+			if err := snapshot.Put(boltSequenceKey(sequence), itemJson); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Commit replaces the snapshot with the staging bucket and saves its info in one transaction.
+func (w *boltDataSetWriter) Commit(info SnapshotInfo) error {
+	infoJson, err := json.Marshal(info)
+
+	if err != nil {
+		w.Abort()
+		return err
+	}
+
+	return w.db.Update(func(tx *bolt.Tx) error {
+		category := tx.Bucket(boltDataSetsBucket).Bucket([]byte(w.categoryId))
+		staging := category.Bucket([]byte(w.staging))
+
+		if category.Bucket([]byte(w.snapshot)) != nil {
+			if err := category.DeleteBucket([]byte(w.snapshot)); err != nil {
+				return err
+			}
+		}
+
+		snapshot, err := category.CreateBucket([]byte(w.snapshot))
+
+		if err != nil {
+			return err
+		}
+
+		err = staging.ForEach(func(key []byte, value []byte) error {
+			return snapshot.Put(key, value)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err := snapshot.SetSequence(staging.Sequence()); err != nil {
+			return err
+		}
+
+		if err := category.DeleteBucket([]byte(w.staging)); err != nil {
+			return err
+		}
+
+		return tx.Bucket(boltSnapshotsBucket).Put(boltSnapshotInfoKey(w.categoryId, w.snapshot), infoJson)
+	})
+}
+
+// Abort removes the staging bucket, and the category bucket when it was the first fetch of the category.
+func (w *boltDataSetWriter) Abort() error {
+	return w.db.Update(func(tx *bolt.Tx) error {
+		categories := tx.Bucket(boltDataSetsBucket)
+		category := categories.Bucket([]byte(w.categoryId))
+
+		if category == nil {
+			return nil
+		}
+
+		if category.Bucket([]byte(w.staging)) != nil {
+			if err := category.DeleteBucket([]byte(w.staging)); err != nil {
+				return err
+			}
+		}
+
+		if key, _ := category.Cursor().First(); key == nil {
+			return categories.DeleteBucket([]byte(w.categoryId))
+		}
+
+		return nil
+	})
+}
+
+func createBoltBuckets(tx *bolt.Tx) error {
+	if _, err := tx.CreateBucketIfNotExists(boltDataSetsBucket); err != nil {
+		return err
+	}
+
+	if _, err := tx.CreateBucketIfNotExists(boltSnapshotsBucket); err != nil {
+		return err
+	}
+
+	_, err := tx.CreateBucketIfNotExists(boltTrainedBucket)
+
+	return err
+}
+
+func boltSnapshotInfoKey(categoryId string, snapshot string) []byte {
+	return []byte(categoryId + "/" + snapshot)
+}
+
+// boltSequenceKey keeps the items in the order they were written.
+func boltSequenceKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return key
+}
@@ -0,0 +1,115 @@
+package suggester
+
+import (
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const CategoryIdBoltTest string = "MLA999007"
+
+func TestBoltStorage(t *testing.T) {
+	folder, _ := ioutil.TempDir("", "bolt")
+	defer os.RemoveAll(folder)
+
+	storage, err := NewBoltStorage(filepath.Join(folder, "suggester.db"))
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	defer storage.Close()
+
+	items := []meli.SearchItem{
+		{Id: "MLA1", Price: 10, CategoryId: CategoryIdBoltTest},
+		{Id: "MLA2", Price: 20, CategoryId: CategoryIdBoltTest},
+	}
+
+	date := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	writer, err := storage.NewDataSetWriter(CategoryIdBoltTest, date, DATA_SET_FORMAT_JSON)
+	assert.Nil(t, err)
+	writer.WritePage(items[:1], 0)
+	writer.WritePage(items[1:], 50)
+	assert.Nil(t, writer.Commit(SnapshotInfo{FetchedAt: date}))
+
+	categories, _ := storage.DataSetCategories()
+	dates, _ := storage.Snapshots(CategoryIdBoltTest)
+
+	t.Log("Given a snapshot written, its category and date are listed.", checkMark)
+	assert.Equal(t, []string{CategoryIdBoltTest}, categories)
+	assert.Equal(t, []time.Time{date}, dates)
+
+	result, err := QueryItems(storage, ItemQuery{CategoryId: CategoryIdBoltTest, MinPrice: 15})
+
+	t.Log("Given a price range, QueryItems returns the items of the snapshot within it.", checkMark)
+	assert.Nil(t, err)
+	assert.Equal(t, items[1:], result)
+
+	hash, _ := storage.SnapshotHash(CategoryIdBoltTest, date)
+
+	writer, _ = storage.NewDataSetWriter(CategoryIdBoltTest, date, DATA_SET_FORMAT_JSON)
+	writer.WritePage(items[:1], 0)
+	writer.Commit(SnapshotInfo{FetchedAt: date})
+
+	changedHash, _ := storage.SnapshotHash(CategoryIdBoltTest, date)
+	result, _ = QueryItems(storage, ItemQuery{CategoryId: CategoryIdBoltTest})
+
+	t.Log("Given a fetch of the same day, the snapshot is replaced and its hash changes.", checkMark)
+	assert.Equal(t, items[:1], result)
+	assert.NotEqual(t, hash, changedHash)
+
+	_, err = storage.ReadTrained(DATA_TRAINED_FILE)
+
+	t.Log("Given a trained file not written, ReadTrained returns a not exist error.", checkMark)
+	assert.True(t, os.IsNotExist(err))
+
+	storage.WriteTrained(DATA_TRAINED_FILE, []byte("{}"))
+	data, _ := storage.ReadTrained(DATA_TRAINED_FILE)
+
+	assert.Equal(t, []byte("{}"), data)
+
+	assert.Nil(t, storage.Clean())
+	categories, _ = storage.DataSetCategories()
+	_, err = storage.ReadTrained(DATA_TRAINED_FILE)
+
+	t.Log("Given Clean, data set and trained files are removed.", checkMark)
+	assert.Empty(t, categories)
+	assert.NotNil(t, err)
+}
+
+func TestSuggester_TrainWithBoltStorage(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	folder, _ := ioutil.TempDir("", "bolt")
+	defer os.RemoveAll(folder)
+
+	storage, err := NewBoltStorage(filepath.Join(folder, "suggester.db"))
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	s := NewSuggester(config, WithStorage(storage))
+	defer s.Close()
+
+	writer, _ := storage.NewDataSetWriter(CategoryIdBoltTest, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), DATA_SET_FORMAT_JSON)
+	writer.WritePage([]meli.SearchItem{
+		{Id: "MLA1", Price: 10, CategoryId: CategoryIdBoltTest},
+		{Id: "MLA2", Price: 30, CategoryId: CategoryIdBoltTest},
+	}, 0)
+	writer.Commit(SnapshotInfo{})
+
+	s.Train()
+
+	suggested, err := s.Suggest(CategoryIdBoltTest)
+
+	t.Log("Given a bolt storage, Train reads the data set and saves the model in it.", checkMark)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(20), suggested.Suggested)
+}
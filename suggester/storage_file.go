@@ -0,0 +1,489 @@
+package suggester
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// SNAPSHOT_DATE_LAYOUT is the layout of the snapshot folders inside a category data set folder.
+	SNAPSHOT_DATE_LAYOUT = "2006-01-02"
+	// SNAPSHOT_INFO_FILE is saved in the snapshot folder and is not a data set file.
+	SNAPSHOT_INFO_FILE = "snapshot-info.json"
+)
+
+// FileStorage keeps the data set in a folder per category and snapshot, and
+// the trained files in the data trained folder.
+type FileStorage struct {
+	dataSetPath     string
+	dataTrainedPath string
+}
+
+// dataSetSnapshot is a dated view of the items of a category. Data sets fetched
+// before snapshots existed are files in the category folder itself and are dated
+// by their last modification.
+type dataSetSnapshot struct {
+	Date  time.Time
+	Path  string
+	Files []string
+	// LegacyFiles are the Files in the category folder itself.
+	LegacyFiles []string
+}
+
+// dataSetItem is an item read from the data set with the category folder and
+// the date of the snapshot it was read from.
+type dataSetItem struct {
+	meli.SearchItem
+	Folder       string
+	SnapshotDate time.Time
+}
+
+func NewFileStorage(dataSetPath string, dataTrainedPath string) *FileStorage {
+	return &FileStorage{
+		dataSetPath:     dataSetPath,
+		dataTrainedPath: dataTrainedPath,
+	}
+}
+
+// DataSetCategories returns the categories with a data set folder, none if
+// nothing was fetched yet.
+func (f *FileStorage) DataSetCategories() ([]string, error) {
+	var categories []string
+
+	dataSetFolder, err := ioutil.ReadDir(f.dataSetPath)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range dataSetFolder {
+		if file.IsDir() {
+			categories = append(categories, file.Name())
+		}
+	}
+
+	return categories, nil
+}
+
+func (f *FileStorage) Snapshots(categoryId string) ([]time.Time, error) {
+	snapshots, err := f.listSnapshots(categoryId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make([]time.Time, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		dates = append(dates, snapshot.Date)
+	}
+
+	return dates, nil
+}
+
+// fileSnapshotWriter writes a snapshot in a staging folder next to it, moved
+// in its place on Commit.
+type fileSnapshotWriter struct {
+	DataSetWriter
+	storage    *FileStorage
+	categoryId string
+	date       time.Time
+	staging    string
+}
+
+func (f *FileStorage) NewDataSetWriter(categoryId string, date time.Time, format string) (SnapshotWriter, error) {
+	staging := f.snapshotPath(categoryId, date) + ".fetching"
+
+	// Pages left by a fetch that did not finish are discarded
+	os.RemoveAll(staging)
+
+	if err := os.MkdirAll(staging, 0777); err != nil {
+		return nil, err
+	}
+
+	writer, err := NewDataSetWriter(format, staging, categoryId)
+
+	if err != nil {
+		os.RemoveAll(staging)
+		return nil, err
+	}
+
+	return &fileSnapshotWriter{DataSetWriter: writer, storage: f, categoryId: categoryId, date: date, staging: staging}, nil
+}
+
+func (w *fileSnapshotWriter) Commit(info SnapshotInfo) error {
+	err := w.DataSetWriter.Close()
+
+	if err == nil {
+		err = writeSnapshotInfo(w.staging, info)
+	}
+
+	if err == nil {
+		err = replaceFolder(w.storage.snapshotPath(w.categoryId, w.date), w.staging)
+	}
+
+	if err != nil {
+		w.discard()
+	}
+
+	return err
+}
+
+func (w *fileSnapshotWriter) Abort() error {
+	err := w.DataSetWriter.Close()
+	w.discard()
+
+	return err
+}
+
+// discard removes the staging folder, and the category folder when it was the first fetch of the category.
+func (w *fileSnapshotWriter) discard() {
+	os.RemoveAll(w.staging)
+	os.Remove(w.storage.categoryPath(w.categoryId))
+}
+
+// ReadSnapshot reads each file of a snapshot.
+func (f *FileStorage) ReadSnapshot(categoryId string, date time.Time, read func(source string, items []meli.SearchItem, err error)) error {
+	snapshot, err := f.findSnapshot(categoryId, date)
+
+	if err != nil {
+		return err
+	}
+
+	for _, filePath := range snapshot.Files {
+		items, err := ReadDataSetFile(filePath)
+		read(filePath, items, err)
+	}
+
+	return nil
+}
+
+// SnapshotHash returns a hash of the names and contents of the files of a snapshot.
+func (f *FileStorage) SnapshotHash(categoryId string, date time.Time) (string, error) {
+	snapshot, err := f.findSnapshot(categoryId, date)
+
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+
+	for _, filePath := range snapshot.Files {
+		io.WriteString(hash, filepath.Base(filePath))
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = io.Copy(hash, file)
+		file.Close()
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (f *FileStorage) WriteSnapshotInfo(categoryId string, date time.Time, info SnapshotInfo) error {
+	snapshotFolder := f.snapshotPath(categoryId, date)
+	createFolder(snapshotFolder)
+
+	return writeSnapshotInfo(snapshotFolder, info)
+}
+
+func writeSnapshotInfo(snapshotFolder string, info SnapshotInfo) error {
+	infoJson, err := json.Marshal(info)
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(snapshotFolder, SNAPSHOT_INFO_FILE), infoJson, DATA_SET_FILE_MODE)
+}
+
+func (f *FileStorage) ReadSnapshotInfo(categoryId string, date time.Time) (SnapshotInfo, error) {
+	var info SnapshotInfo
+
+	infoJson, err := ioutil.ReadFile(filepath.Join(f.snapshotPath(categoryId, date), SNAPSHOT_INFO_FILE))
+
+	if err != nil {
+		return info, err
+	}
+
+	err = json.Unmarshal(infoJson, &info)
+
+	return info, err
+}
+
+func (f *FileStorage) ReadTrained(name string) ([]byte, error) {
+	return ioutil.ReadFile(f.trainedPath(name))
+}
+
+func (f *FileStorage) WriteTrained(name string, data []byte) error {
+	createFolder(f.dataTrainedPath)
+
+	return ioutil.WriteFile(f.trainedPath(name), data, DATA_SET_FILE_MODE)
+}
+
+// Clean removes data set and data trained folders.
+func (f *FileStorage) Clean() error {
+	err := os.RemoveAll(f.dataSetPath)
+
+	if trainedErr := os.RemoveAll(f.dataTrainedPath); err == nil {
+		err = trainedErr
+	}
+
+	return err
+}
+
+func (f *FileStorage) Close() error {
+	return nil
+}
+
+// ConvertDataSet rewrites every snapshot of a category in format. Files
+// directly in the category folder are moved to a snapshot of their date.
+func (f *FileStorage) ConvertDataSet(categoryId string, format string) error {
+	snapshots, err := f.listSnapshots(categoryId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshotIsFormat(snapshot, format) && len(snapshot.LegacyFiles) == 0 {
+			continue
+		}
+
+		var items []meli.SearchItem
+
+		for _, filePath := range snapshot.Files {
+			fileItems, err := ReadDataSetFile(filePath)
+
+			if err != nil {
+				return errors.New(fmt.Sprintf("Error reading data set file: %s %s", filePath, err))
+			}
+
+			items = append(items, fileItems...)
+		}
+
+		// Write to a temporary folder first, so a failure leaves the snapshot untouched
+		snapshotFolder := f.snapshotPath(categoryId, snapshot.Date)
+		convertFolder := snapshotFolder + ".converting"
+
+		createFolder(convertFolder)
+
+		writer, err := NewDataSetWriter(format, convertFolder, categoryId)
+
+		if err == nil {
+			err = writer.WritePage(items, 0)
+
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+
+		if err == nil {
+			err = copySnapshotInfo(snapshotFolder, convertFolder)
+		}
+
+		if err == nil {
+			// The snapshot is moved aside before the converted one takes its place, and removed last
+			err = replaceFolder(snapshotFolder, convertFolder)
+		}
+
+		if err != nil {
+			os.RemoveAll(convertFolder)
+			return err
+		}
+
+		// Their items are in the snapshot now, they would be read twice otherwise
+		for _, filePath := range snapshot.LegacyFiles {
+			os.Remove(filePath)
+		}
+	}
+
+	return nil
+}
+
+func (f *FileStorage) trainedPath(name string) string {
+	return filepath.Join(f.dataTrainedPath, name)
+}
+
+func (f *FileStorage) categoryPath(categoryId string) string {
+	return filepath.Join(f.dataSetPath, categoryId)
+}
+
+// snapshotPath returns the folder where a fetch of categoryId at date is saved.
+func (f *FileStorage) snapshotPath(categoryId string, date time.Time) string {
+	return filepath.Join(f.dataSetPath, categoryId, date.Format(SNAPSHOT_DATE_LAYOUT))
+}
+
+func (f *FileStorage) findSnapshot(categoryId string, date time.Time) (dataSetSnapshot, error) {
+	snapshots, err := f.listSnapshots(categoryId)
+
+	if err != nil {
+		return dataSetSnapshot{}, err
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.Date.Equal(date) {
+			return snapshot, nil
+		}
+	}
+
+	return dataSetSnapshot{}, errors.New(fmt.Sprintf("Snapshot: %s of category: %s not found.", date.Format(SNAPSHOT_DATE_LAYOUT), categoryId))
+}
+
+// listSnapshots returns the snapshots of a category sorted by date, oldest
+// first. Files in the category folder dated as a snapshot are part of it.
+func (f *FileStorage) listSnapshots(categoryId string) ([]dataSetSnapshot, error) {
+	var snapshots []dataSetSnapshot
+
+	categoryDataSetPath := f.categoryPath(categoryId)
+
+	entries, err := ioutil.ReadDir(categoryDataSetPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	legacy := dataSetSnapshot{Path: categoryDataSetPath}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(categoryDataSetPath, entry.Name())
+
+		if !entry.IsDir() {
+			if !isItemFile(entry.Name()) {
+				continue
+			}
+
+			legacy.Files = append(legacy.Files, entryPath)
+			legacy.LegacyFiles = append(legacy.LegacyFiles, entryPath)
+
+			if entry.ModTime().After(legacy.Date) {
+				legacy.Date = entry.ModTime()
+			}
+			continue
+		}
+
+		date, err := time.Parse(SNAPSHOT_DATE_LAYOUT, entry.Name())
+
+		if err != nil {
+			continue
+		}
+
+		files, err := listItemFiles(entryPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, dataSetSnapshot{Date: date, Path: entryPath, Files: files})
+	}
+
+	if len(legacy.Files) > 0 {
+		legacy.Date = truncateToDay(legacy.Date)
+		snapshots = mergeLegacySnapshot(snapshots, legacy)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Date.Before(snapshots[j].Date)
+	})
+
+	return snapshots, nil
+}
+
+func mergeLegacySnapshot(snapshots []dataSetSnapshot, legacy dataSetSnapshot) []dataSetSnapshot {
+	for index, snapshot := range snapshots {
+		if snapshot.Date.Equal(legacy.Date) {
+			snapshots[index].Files = append(snapshots[index].Files, legacy.Files...)
+			snapshots[index].LegacyFiles = legacy.LegacyFiles
+			return snapshots
+		}
+	}
+	return append(snapshots, legacy)
+}
+
+func listItemFiles(path string) ([]string, error) {
+	var files []string
+
+	entries, err := ioutil.ReadDir(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() && isItemFile(entry.Name()) {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+
+	return files, nil
+}
+
+func isItemFile(name string) bool {
+	_, ok := dataSetFileFormat(name)
+	return ok && filepath.Base(name) != SNAPSHOT_INFO_FILE
+}
+
+// copySnapshotInfo copies the snapshot info of snapshotFolder, if any, to folder.
+func copySnapshotInfo(snapshotFolder string, folder string) error {
+	info, err := ioutil.ReadFile(filepath.Join(snapshotFolder, SNAPSHOT_INFO_FILE))
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(folder, SNAPSHOT_INFO_FILE), info, DATA_SET_FILE_MODE)
+}
+
+// replaceFolder moves folder to path, replacing the folder there. That one is
+// moved aside first and removed last, so it is only lost once folder is in place.
+func replaceFolder(path string, folder string) error {
+	previous := path + ".replaced"
+	os.RemoveAll(previous)
+
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Rename(path, previous); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(folder, path); err != nil {
+		os.Rename(previous, path)
+		return err
+	}
+
+	return os.RemoveAll(previous)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func createFolder(path string) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		os.MkdirAll(path, 0777)
+	}
+}
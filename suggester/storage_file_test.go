@@ -0,0 +1,114 @@
+package suggester
+
+import (
+	"encoding/json"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const CategoryIdSnapshotTest string = "MLA999003"
+
+func TestListSnapshots(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	categoryPath := filepath.Join(config.DataSetPath, CategoryIdSnapshotTest)
+
+	os.MkdirAll(categoryPath+"/2018-03-01", 0777)
+	os.MkdirAll(categoryPath+"/2018-01-01", 0777)
+	os.MkdirAll(categoryPath+"/not-a-date", 0777)
+	ioutil.WriteFile(categoryPath+"/2018-03-01/"+CategoryIdSnapshotTest+"-0.json", []byte("[]"), 0777)
+	ioutil.WriteFile(categoryPath+"/2018-01-01/"+CategoryIdSnapshotTest+"-0.json", []byte("[]"), 0777)
+
+	storage := NewFileStorage(config.DataSetPath, config.DataTrainedPath)
+
+	snapshots, err := storage.listSnapshots(CategoryIdSnapshotTest)
+
+	assert.Nil(t, err)
+	if assert.Len(t, snapshots, 2) {
+		t.Log("listSnapshots returns dated snapshots sorted oldest first.", checkMark)
+		assert.Equal(t, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), snapshots[0].Date)
+		assert.Equal(t, filepath.Join(categoryPath, "2018-03-01"), snapshots[1].Path)
+		assert.Len(t, snapshots[1].Files, 1)
+	}
+
+	date, ok, err := latestSnapshotDate(storage, CategoryIdSnapshotTest)
+
+	t.Log("latestSnapshotDate returns the date of the most recent snapshot.", checkMark)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC), date)
+}
+
+func TestListSnapshotsLegacy(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	categoryPath := filepath.Join(config.DataSetPath, CategoryIdSnapshotTest)
+
+	os.MkdirAll(categoryPath, 0777)
+	ioutil.WriteFile(categoryPath+"/"+CategoryIdSnapshotTest+"-0.json", []byte("[]"), 0777)
+
+	storage := NewFileStorage(config.DataSetPath, config.DataTrainedPath)
+
+	snapshots, err := storage.listSnapshots(CategoryIdSnapshotTest)
+
+	assert.Nil(t, err)
+	if assert.Len(t, snapshots, 1) {
+		t.Log("Given files in the category folder, listSnapshots returns them as a snapshot.", checkMark)
+		assert.Equal(t, filepath.Clean(categoryPath), snapshots[0].Path)
+	}
+
+	// A fetch on the same day of the legacy files
+	os.MkdirAll(storage.snapshotPath(CategoryIdSnapshotTest, snapshots[0].Date), 0777)
+	ioutil.WriteFile(storage.snapshotPath(CategoryIdSnapshotTest, snapshots[0].Date)+"/"+CategoryIdSnapshotTest+"-0.json", []byte("[]"), 0777)
+
+	snapshots, _ = storage.listSnapshots(CategoryIdSnapshotTest)
+
+	if assert.Len(t, snapshots, 1) {
+		t.Log("Given a snapshot dated as the legacy files, listSnapshots merges them.", checkMark)
+		assert.Len(t, snapshots[0].Files, 2)
+	}
+}
+
+func TestFileStorage_QueryItems(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	categoryPath := filepath.Join(config.DataSetPath, CategoryIdSnapshotTest)
+
+	items := []meli.SearchItem{
+		{Id: "MLA1", Price: 10, CategoryId: CategoryIdSnapshotTest},
+		{Id: "MLA2", Price: 20, CategoryId: CategoryIdSnapshotTest},
+		{Id: "MLA3", Price: 30, CategoryId: CategoryIdSnapshotTest},
+	}
+	itemsJson, _ := json.Marshal(items)
+	oldItemsJson, _ := json.Marshal(items[:1])
+
+	os.MkdirAll(categoryPath+"/2018-01-01", 0777)
+	os.MkdirAll(categoryPath+"/2018-02-01", 0777)
+	ioutil.WriteFile(categoryPath+"/2018-01-01/"+CategoryIdSnapshotTest+"-0.json", oldItemsJson, 0777)
+	ioutil.WriteFile(categoryPath+"/2018-02-01/"+CategoryIdSnapshotTest+"-0.json", itemsJson, 0777)
+
+	storage := NewFileStorage(config.DataSetPath, config.DataTrainedPath)
+
+	result, err := QueryItems(storage, ItemQuery{CategoryId: CategoryIdSnapshotTest, MinPrice: 15})
+
+	assert.Nil(t, err)
+	t.Log("Given a min price, QueryItems returns the items of the latest snapshot from it.", checkMark)
+	assert.ElementsMatch(t, items[1:], result)
+
+	result, _ = QueryItems(storage, ItemQuery{CategoryId: CategoryIdSnapshotTest, MaxPrice: 20})
+
+	t.Log("Given a max price, QueryItems returns the items up to it.", checkMark)
+	assert.ElementsMatch(t, items[:2], result)
+
+	result, _ = QueryItems(storage, ItemQuery{CategoryId: CategoryIdSnapshotTest, Snapshot: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)})
+
+	t.Log("Given a snapshot date, QueryItems returns the items of that snapshot.", checkMark)
+	assert.ElementsMatch(t, items[:1], result)
+}
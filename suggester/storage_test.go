@@ -0,0 +1,78 @@
+package suggester
+
+import (
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const CategoryIdReplaceTest string = "MLA999014"
+
+func TestStorage_ReplaceSnapshot(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	folder, _ := ioutil.TempDir("", "bolt")
+	defer os.RemoveAll(folder)
+
+	boltStorage, err := NewBoltStorage(filepath.Join(folder, "suggester.db"))
+
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	defer boltStorage.Close()
+
+	storages := map[string]Storage{
+		STORAGE_FILE: NewFileStorage(config.DataSetPath, config.DataTrainedPath),
+		STORAGE_BOLT: boltStorage,
+	}
+
+	date := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := []meli.SearchItem{{Id: "MLA1", Price: 10}, {Id: "MLA2", Price: 20}}
+	second := []meli.SearchItem{{Id: "MLA3", Price: 30}}
+
+	for name, storage := range storages {
+		writer, _ := storage.NewDataSetWriter(CategoryIdReplaceTest, date, DATA_SET_FORMAT_JSON)
+		writer.WritePage(first[:1], 0)
+		writer.WritePage(first[1:], 50)
+		assert.Nil(t, writer.Commit(SnapshotInfo{FetchedAt: date, Items: 2}), name)
+
+		writer, _ = storage.NewDataSetWriter(CategoryIdReplaceTest, date, DATA_SET_FORMAT_JSON)
+		writer.WritePage(second, 0)
+		assert.Nil(t, writer.Abort(), name)
+
+		result, _ := QueryItems(storage, ItemQuery{CategoryId: CategoryIdReplaceTest})
+		info, _ := storage.ReadSnapshotInfo(CategoryIdReplaceTest, date)
+
+		t.Log("Given a fetch of the same day aborted with "+name+" storage, the snapshot is kept.", checkMark)
+		assert.Equal(t, first, result, name)
+		assert.Equal(t, 2, info.Items, name)
+
+		writer, _ = storage.NewDataSetWriter(CategoryIdReplaceTest, date, DATA_SET_FORMAT_JSON)
+		writer.WritePage(second, 0)
+		assert.Nil(t, writer.Commit(SnapshotInfo{FetchedAt: date, Items: 1}), name)
+
+		result, _ = QueryItems(storage, ItemQuery{CategoryId: CategoryIdReplaceTest})
+		info, _ = storage.ReadSnapshotInfo(CategoryIdReplaceTest, date)
+		dates, _ := storage.Snapshots(CategoryIdReplaceTest)
+
+		t.Log("Given a fetch of the same day committed with "+name+" storage, only its items are in the snapshot.", checkMark)
+		assert.Equal(t, second, result, name)
+		assert.Equal(t, 1, info.Items, name)
+		assert.Equal(t, []time.Time{date}, dates, name)
+
+		writer, _ = storage.NewDataSetWriter(CategoryIdTest, date, DATA_SET_FORMAT_JSON)
+		writer.WritePage(second, 0)
+		writer.Abort()
+
+		categories, _ := storage.DataSetCategories()
+
+		t.Log("Given the first fetch of a category aborted with "+name+" storage, the category is not listed.", checkMark)
+		assert.Equal(t, []string{CategoryIdReplaceTest}, categories, name)
+	}
+}
@@ -6,103 +6,179 @@ import (
 	"fmt"
 	"github.com/jesusfar/meli.price.suggester/meli"
 	"github.com/jesusfar/meli.price.suggester/util"
-	"io/ioutil"
-	"os"
+	"math/rand"
 	"sync"
-	//"time"
+	"time"
 )
 
 const (
-	FETCH_DATA_SET         string = "fetch"
-	TRAIN_MODEL            string = "train"
-	SUGGEST                string = "suggest"
-	SERVE                  string = "serve"
-	CLEAN                  string = "clean"
-	DATA_SET_PATH                 = "./dataset/"
-	DATA_TRAINED_PATH             = "./datatrained/"
-	DATA_TRAINED_FILE_PATH        = DATA_TRAINED_PATH + "datatrained.json"
+	FETCH_DATA_SET    string = "fetch"
+	TRAIN_MODEL       string = "train"
+	SUGGEST           string = "suggest"
+	SERVE             string = "serve"
+	CLEAN             string = "clean"
+	EVALUATE          string = "evaluate"
+	DIFF              string = "diff"
+	TREND             string = "trend"
+	DATA_SET          string = "dataset"
+	DATA_SET_CONVERT  string = "convert"
+	DATA_SET_QUERY    string = "query"
+	DATA_SET_STATS    string = "stats"
+	DATA_SET_VALIDATE string = "validate"
+	DATA_SET_PATH            = "./dataset/"
+	DATA_TRAINED_PATH        = "./datatrained/"
 )
 
 type DataTrained struct {
 	sync.RWMutex
-	data map[string]CategoryPriceTrained
+	data      map[string]CategoryPriceTrained
+	version   string
+	trainedAt time.Time
+	items     int
+	coverage  ModelCoverage
+	tree      categoryTree
+	names     map[string]string
 }
 
 type CategoryPriceTrained struct {
-	Max       float64
-	Suggested float64
-	Min       float64
-	Sum       float64
-	Total     float64
+	Max          float64
+	Suggested    float64
+	Min          float64
+	Sum          float64
+	Total        float64
+	SnapshotDate time.Time
 }
 
 type CategoryPriceSuggested struct {
-	Max       float64 `json:"max"`
-	Suggested float64 `json:"suggested"`
-	Min       float64 `json:"min"`
+	Max        float64          `json:"max"`
+	Suggested  float64          `json:"suggested"`
+	Min        float64          `json:"min"`
+	Adjustment *PriceAdjustment `json:"adjustment,omitempty"`
 }
 
-type Suggester struct {
-	meliClient          meli.MeliClient
-	inMemoryDataTrained *DataTrained
-	logger              *util.Logger
+// model is what prices are suggested with. It is replaced whole and never
+// changed, so a reader that took it with currentModel sees the data trained,
+// price history and price index of the same load while a job reloads them.
+type model struct {
+	dataTrained  *DataTrained
+	priceHistory map[string][]PricePoint
+	// priceHistoryVersion and priceHistoryAt tell the price history apart, as
+	// it is loaded separately from the data trained.
+	priceHistoryVersion string
+	priceHistoryAt      time.Time
+	priceIndex          PriceIndex
 }
 
-// NewSuggester returns a suggester for category price.
-func NewSuggester() *Suggester {
-	meliClient := meli.NewMeliHttpClient()
+type Suggester struct {
+	config             Config
+	meliClient         meli.MeliClient
+	storage            Storage
+	modelLock          sync.RWMutex
+	model              model
+	deduplicateOnFetch bool
+	dataSetFormat      string
+	logger             Logger
+	now                func() time.Time
+	rand               *rand.Rand
+	observer           Observer
+}
 
+// NewSuggester returns a suggester for category price configured by config.
+// Dependencies not set by options are built from config. It does no I/O, the
+// model has to be loaded with LoadModel before suggesting prices.
+func NewSuggester(config Config, options ...Option) *Suggester {
 	suggester := &Suggester{
-		meliClient: meliClient,
-		logger:     util.NewLogger(),
+		config:        config,
+		dataSetFormat: config.DataSetFormat,
+	}
+
+	for _, option := range options {
+		option(suggester)
+	}
+
+	if suggester.meliClient == nil {
+		meliClient := meli.NewMeliHttpClient()
+		meliClient.SetEndpoint(config.Endpoint)
+		meliClient.SetRetryPolicy(config.Retry.MaxRetries, config.RetryDelay())
+		suggester.meliClient = meliClient
 	}
 
-	// LoadDataTrained if exists data trained file
-	suggester.LoadDataTrained()
+	if suggester.logger == nil {
+		suggester.logger = util.NewLogger()
+	}
+
+	if suggester.storage == nil {
+		suggester.storage = NewFileStorage(config.DataSetPath, config.DataTrainedPath)
+	}
+
+	if suggester.now == nil {
+		suggester.now = time.Now
+	}
+
+	if suggester.rand == nil {
+		suggester.rand = rand.New(rand.NewSource(suggester.now().UnixNano()))
+	}
 
 	return suggester
 }
 
-// FetchDataSet fetches items from Meli and save data in dataset folder
-func (s *Suggester) FetchDataSet(site string) {
+// FetchDataSet fetches items from Meli and save data in dataset folder. The
+// categories that fail are returned as CategoryErrors after fetching the rest.
+func (s *Suggester) FetchDataSet(site string) error {
 
 	s.logger.Info("[FetchDataSet] Fetching data set ...")
 
-	// Create folder if not exists
-	createFolder(DATA_SET_PATH)
-
 	// Fetch categories for site
 	categories, err := s.meliClient.GetCategories(site)
 
 	if err != nil {
 		s.logger.Info("[FetchDataSet] Error fetching categories. Please see in DEBUG mode")
 		s.logger.Debug(err)
-		return
+		return err
 	}
 
-	// Foreach category we need to search items related
+	categoryIds := make([]string, 0, len(categories))
 	for _, category := range categories {
-		s.logger.Debug("[FetchDataSet] Fetching items for category: " + category.Id)
-		s.FetchItemsBySystematicRandomSampling(site, category.Id)
+		categoryIds = append(categoryIds, category.Id)
+	}
+
+	return s.FetchCategories(site, categoryIds)
+}
+
+// FetchCategories fetches a snapshot of each category. The categories that
+// fail are returned as CategoryErrors after fetching the rest.
+func (s *Suggester) FetchCategories(site string, categoryIds []string) error {
+	var categoryErrors CategoryErrors
+
+	progress := s.newProgress(FETCH_DATA_SET, len(categoryIds))
+
+	// Foreach category we need to search items related
+	for _, categoryId := range categoryIds {
+		s.logger.Debug("[FetchDataSet] Fetching items for category: " + categoryId)
+
+		if err := s.fetchCategory(site, categoryId, progress); err != nil {
+			categoryErrors = append(categoryErrors, err)
+		}
 	}
 
-	s.logger.Info("[FetchDataSet] Fetching done.")
+	s.logger.Info(fmt.Sprintf("[FetchDataSet] Fetching done, %d of %d categories failed.", len(categoryErrors), len(categoryIds)))
+
+	return categoryErrors.errorOrNil()
 }
 
 // Suggest a price for categoryId
 func (s *Suggester) Suggest(categoryId string) (CategoryPriceSuggested, error) {
+	return s.currentModel().suggest(categoryId)
+}
+
+func (m model) suggest(categoryId string) (CategoryPriceSuggested, error) {
 	var suggested CategoryPriceSuggested
 
-	// Try to load data trained.
-	if s.inMemoryDataTrained == nil {
-		err := s.LoadDataTrained()
-		if err != nil {
-			s.logger.Warning("[Predict] Error loading data trained.")
-			return suggested, err
-		}
+	if m.dataTrained == nil {
+		return suggested, ErrModelNotLoaded
 	}
 
-	result, ok := s.inMemoryDataTrained.data[categoryId]
+	result, ok := m.dataTrained.data[categoryId]
 
 	if ok {
 		suggested.Max = result.Max
@@ -110,134 +186,392 @@ func (s *Suggester) Suggest(categoryId string) (CategoryPriceSuggested, error) {
 		suggested.Min = result.Min
 		return suggested, nil
 	} else {
-		err := errors.New(fmt.Sprintf("Category: %s not found.", categoryId))
-		return suggested, err
+		return suggested, errCategoryNotFound(categoryId)
 	}
 }
 
 // Train reads the dataSet and prepare the model to predict the price by categoryID
-func (s *Suggester) Train() {
+func (s *Suggester) Train() error {
+	_, err := s.TrainWithOptions(TrainOptions{})
+	return err
+}
+
+// TrainWithOptions trains the data set folders selected by options and merges
+// them with the folders of the last training that are not retrained. The
+// report of the run is returned and saved next to the model, also when the run
+// fails. The folders that can not be read are returned as CategoryErrors after
+// training the rest. A run out of the thresholds of options returns a
+// ThresholdsError without saving the model.
+func (s *Suggester) TrainWithOptions(options TrainOptions) (report TrainReport, err error) {
+
+	startedAt := s.now()
 
 	wgItemProducer := &sync.WaitGroup{}
 	wgItemConsumer := &sync.WaitGroup{}
 
-	dataTrained := &DataTrained{data: make(map[string]CategoryPriceTrained)}
+	if options.Attribution == "" {
+		options.Attribution = s.config.Attribution
+	}
+
+	outPutItemChannel := make(chan *dataSetItem, 20)
+
+	report = newTrainReport(startedAt, options.Attribution)
+
+	defer func() {
+		report.Duration = s.now().Sub(startedAt).Seconds()
 
-	outPutItemChannel := make(chan *meli.SearchItem, 20)
+		if _, partial := err.(CategoryErrors); err != nil && !partial {
+			report.Error = err.Error()
+		}
+
+		if saveErr := s.saveTrainReport(report); err == nil {
+			err = saveErr
+		}
+	}()
 
-	// Read dataSet path
-	dataSetFolder, err := ioutil.ReadDir(DATA_SET_PATH)
+	categories, err := s.storage.DataSetCategories()
 
 	if err != nil {
-		s.logger.Warning("[Train] Error reading ./dataset/ folder")
+		s.logger.Warning("[Train] Error reading dataset categories.")
+		s.logger.Debug(err)
+		return report, err
 	}
 
-	for _, file := range dataSetFolder {
-		if file.IsDir() {
-			categoryId := file.Name()
-			s.logger.Debug("[Train] Starting train dataset for category: " + categoryId)
+	tree := s.loadCategoryTree(categories)
+	foldersTrained := newFoldersTrained(options.DropNearDuplicates, attribution{policy: options.Attribution, tree: tree})
+
+	manifest := TrainManifest{Folders: make(map[string]FolderTrained)}
 
-			wgItemProducer.Add(1)
-			go s.readItemFilesForCategory(categoryId, outPutItemChannel, wgItemProducer)
+	if options.Incremental || len(options.Categories) > 0 {
+		manifest = s.loadTrainManifest()
+
+		// Folders trained with another attribution can not be merged
+		if len(manifest.Folders) > 0 && manifest.Attribution != options.Attribution {
+			s.logger.Info(fmt.Sprintf("[Train] Attribution changed from: %s to: %s, training every category.", manifest.Attribution, options.Attribution))
+			manifest = TrainManifest{Folders: make(map[string]FolderTrained)}
+		}
 
-			wgItemConsumer.Add(1)
-			go s.trainModel(dataTrained, outPutItemChannel, wgItemConsumer)
+		// Without the last training every category has to be trained
+		if len(manifest.Folders) == 0 {
+			options.Categories = nil
 		}
 	}
 
-	s.logger.Info("[Train] Waiting to finish")
+	manifest.Attribution = options.Attribution
+
+	folderHashes := s.selectFoldersToTrain(categories, manifest, options)
+
+	for categoryId := range folderHashes {
+		report.Categories[categoryId] = newCategoryTrainReport()
+	}
+
+	progress := s.newProgress(TRAIN_MODEL, len(folderHashes))
+
+	for categoryId := range folderHashes {
+		s.logger.Debug("[Train] Starting train dataset for category: " + categoryId)
+
+		wgItemProducer.Add(1)
+		go s.readItemFilesForCategory(categoryId, report.Categories[categoryId], progress, outPutItemChannel, wgItemProducer)
+
+		wgItemConsumer.Add(1)
+		go s.trainModel(foldersTrained, outPutItemChannel, wgItemConsumer)
+	}
+
+	s.logger.Info(fmt.Sprintf("[Train] Waiting to finish %d categories", len(folderHashes)))
 
 	wgItemProducer.Wait()
 	close(outPutItemChannel)
 
 	wgItemConsumer.Wait()
 
-	dataTrainedForSave, _ := json.Marshal(dataTrained.data)
+	for categoryId, hash := range folderHashes {
+		// A folder that can not be read keeps what it contributed to the last training
+		if _, ok := manifest.Folders[categoryId]; ok && report.Categories[categoryId].Error != "" {
+			s.logger.Warning(fmt.Sprintf("[Train][%s] Folder not read, keeping its last training.", categoryId))
+			continue
+		}
+
+		deduplicator := foldersTrained.deduplicator(categoryId)
+
+		s.logger.Info(fmt.Sprintf("[Train][%s] Duplicates: %d Near-duplicates: %d", categoryId, deduplicator.Duplicates, deduplicator.NearDuplicates))
+
+		categoryReport := report.Categories[categoryId]
+		categoryReport.Dropped[DROPPED_DUPLICATE] = deduplicator.Duplicates
+		if options.DropNearDuplicates {
+			categoryReport.Dropped[DROPPED_NEAR_DUPLICATE] = deduplicator.NearDuplicates
+		}
+		categoryReport.Trained = categoryReport.Items - categoryReport.Dropped[DROPPED_DUPLICATE] - categoryReport.Dropped[DROPPED_NEAR_DUPLICATE]
+
+		manifest.Folders[categoryId] = FolderTrained{
+			Hash:           hash,
+			TrainedAt:      s.now(),
+			Duplicates:     deduplicator.Duplicates,
+			NearDuplicates: deduplicator.NearDuplicates,
+			Categories:     foldersTrained.data[categoryId],
+			History:        s.folderPriceHistory(categoryId, foldersTrained.attribution),
+			Items:          categoryReport.Trained,
+			CategoryPath:   tree.path(categoryId),
+			CategoryNames:  s.snapshotCategoryNames(categoryId),
+		}
+	}
+
+	dataTrained := manifest.DataTrained()
+
+	for categoryId, categoryReport := range report.Categories {
+		if trained, ok := dataTrained[categoryId]; ok {
+			categoryReport.Stats = &trained
+		}
+	}
+
+	report.ModelCategories = len(dataTrained)
+	report.summarize()
+
+	// A model out of the thresholds is not published
+	if options.Thresholds != nil {
+		if violations := report.Violations(*options.Thresholds); len(violations) > 0 {
+			s.logger.Warning("[Train] Training exceeds thresholds, model not saved.")
+			return report, ThresholdsError{Violations: violations}
+		}
+	}
 
-	createFolder(DATA_TRAINED_PATH)
+	dataTrainedForSave, _ := json.Marshal(dataTrained)
 
-	err = ioutil.WriteFile(DATA_TRAINED_FILE_PATH, dataTrainedForSave, 0777)
+	err = s.storage.WriteTrained(DATA_TRAINED_FILE, dataTrainedForSave)
 
 	if err != nil {
 		s.logger.Warning("[Train] Error writing data trained.")
 		s.logger.Debug(err)
+		return report, err
+	}
+
+	priceHistoryForSave, _ := json.Marshal(manifest.PriceHistory())
+
+	err = s.storage.WriteTrained(PRICE_HISTORY_FILE, priceHistoryForSave)
+
+	if err != nil {
+		s.logger.Warning("[Train] Error writing price history.")
+		s.logger.Debug(err)
+		return report, err
 	}
 
-	// Reset dataTrained in Suggester
-	s.inMemoryDataTrained = nil
+	if err := s.saveTrainManifest(manifest); err != nil {
+		return report, err
+	}
+
+	// Suggest with the data trained
+	trainedAt := s.now()
+	newDataTrained := s.newDataTrained(dataTrained, trainedAt, manifest)
+	priceHistory := manifest.PriceHistory()
+	historyVersion := priceHistoryVersion(priceHistory)
+
+	s.updateModel(func(m *model) {
+		m.dataTrained = newDataTrained
+		m.priceHistory, m.priceHistoryVersion, m.priceHistoryAt = priceHistory, historyVersion, trainedAt
+	})
+
+	s.logger.Info(fmt.Sprintf("[Train] Train finished, items: %d trained: %d unreadable files: %d", report.Items, report.Trained, report.UnreadableFiles))
 
-	s.logger.Info("[Train] Train finished")
+	return report, report.categoryErrors().errorOrNil()
 }
 
 // LoadDataTrained loads data trained from file if exist and keep in memory.
 func (s *Suggester) LoadDataTrained() error {
-	var dataTrained map[string]CategoryPriceTrained
-
-	dataTrainedFile, err := ioutil.ReadFile(DATA_TRAINED_FILE_PATH)
+	dataTrained, err := s.readDataTrained()
 
 	if err != nil {
-		s.logger.Warning("[LoadDataTrained][Notice] Data trained file: %s does not exist.", DATA_TRAINED_FILE_PATH)
 		return err
 	}
 
-	err = json.Unmarshal(dataTrainedFile, &dataTrained)
+	s.updateModel(func(m *model) {
+		m.dataTrained = dataTrained
+	})
+
+	s.logger.Info("[LoadDataTrained][Notice]  Data trained load [OK]")
+
+	return nil
+}
+
+func (s *Suggester) readDataTrained() (*DataTrained, error) {
+	dataTrainedFile, err := s.storage.ReadTrained(DATA_TRAINED_FILE)
 
 	if err != nil {
-		s.logger.Warning(fmt.Sprintf("[LoadDataTrained][Notice] Error Unmarshal file: %d ", DATA_TRAINED_FILE_PATH))
-		s.logger.Debug(err)
-		return err
+		s.logger.Warning(fmt.Sprintf("[LoadDataTrained][Notice] Data trained file: %s does not exist.", DATA_TRAINED_FILE))
+		return nil, err
 	}
 
-	s.SetInMemoryDataTrained(dataTrained)
+	dataTrained, err := DecodeModel(dataTrainedFile)
 
-	s.logger.Info("[LoadDataTrained][Notice]  Data trained load [OK]")
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("[LoadDataTrained][Notice] Error Unmarshal file: %s ", DATA_TRAINED_FILE))
+		s.logger.Debug(err)
+		return nil, err
+	}
 
-	return nil
+	// The model was trained when its last folder was
+	manifest := s.loadTrainManifest()
+	var trainedAt time.Time
+	for _, folder := range manifest.Folders {
+		if folder.TrainedAt.After(trainedAt) {
+			trainedAt = folder.TrainedAt
+		}
+	}
+
+	return s.newDataTrained(dataTrained, trainedAt, manifest), nil
 }
 
+// SetInMemoryDataTrained sets the model to suggest with, trained now.
 func (s *Suggester) SetInMemoryDataTrained(data map[string]CategoryPriceTrained) {
+	dataTrained := s.newDataTrained(data, s.now(), TrainManifest{})
+
+	s.updateModel(func(m *model) {
+		m.dataTrained = dataTrained
+	})
+}
+
+func (s *Suggester) newDataTrained(data map[string]CategoryPriceTrained, trainedAt time.Time, manifest TrainManifest) *DataTrained {
 	s.logger.Info("[SetInMemoryDataTrained] Set in memory data trained.")
-	s.inMemoryDataTrained = &DataTrained{data: data}
+
+	tree, names := manifest.categoryTree()
+
+	return &DataTrained{
+		data:      data,
+		version:   modelVersion(data),
+		trainedAt: trainedAt,
+		items:     manifest.Items(),
+		coverage:  s.modelCoverage(manifest),
+		tree:      tree,
+		names:     names,
+	}
 }
 
 func (s *Suggester) GetInMemoryDataTrained() *DataTrained {
-	return s.inMemoryDataTrained
+	return s.currentModel().dataTrained
 }
 
-func (s *Suggester) FetchItemsBySystematicRandomSampling(site string, categoryId string) {
+// currentModel returns the model in memory.
+func (s *Suggester) currentModel() model {
+	s.modelLock.RLock()
+	defer s.modelLock.RUnlock()
+
+	return s.model
+}
+
+// updateModel replaces the model in memory with a copy changed by update.
+func (s *Suggester) updateModel(update func(m *model)) {
+	s.modelLock.Lock()
+	defer s.modelLock.Unlock()
+
+	next := s.model
+	update(&next)
+	s.model = next
+}
+
+// FetchItemsBySystematicRandomSampling fetches a sample of the items of
+// categoryId as a new snapshot. A failure is returned as a *CategoryError, and
+// leaves the snapshot fetched before untouched.
+func (s *Suggester) FetchItemsBySystematicRandomSampling(site string, categoryId string) error {
+	if err := s.fetchCategory(site, categoryId, s.newProgress(FETCH_DATA_SET, 1)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// fetchCategory fetches a snapshot of categoryId, reporting it to progress.
+func (s *Suggester) fetchCategory(site string, categoryId string, progress *progress) *CategoryError {
+	progress.categoryStarted(categoryId)
+
+	if err := s.fetchItemsBySystematicRandomSampling(site, categoryId, progress); err != nil {
+		progress.categoryFinished(categoryId, err.Error())
+		return &CategoryError{Op: FETCH_DATA_SET, CategoryId: categoryId, Err: err}
+	}
+
+	progress.categoryFinished(categoryId, "")
+
+	return nil
+}
+
+func (s *Suggester) fetchItemsBySystematicRandomSampling(site string, categoryId string, progress *progress) (err error) {
 
 	query := "category=" + categoryId
 	offset := 0
-	limit := 50
+	limit := s.config.Sampling.PageSize
+
+	deduplicator := newItemDeduplicator()
+
+	fetchedAt := s.now()
 
-	createFolder(DATA_SET_PATH + categoryId)
+	// Each fetch is saved as a dated snapshot of the category
+	writer, err := s.storage.NewDataSetWriter(categoryId, fetchedAt, s.dataSetFormat)
+
+	if err != nil {
+		s.logger.Warning("[fetchRandomItemsByCategory] Error creating dataset writer.")
+		s.logger.Debug(err)
+		return err
+	}
+
+	info := &SnapshotInfo{
+		Site:      site,
+		FetchedAt: fetchedAt,
+		Format:    s.dataSetFormat,
+		Sampling:  s.config.Sampling,
+	}
+
+	// The snapshot is only replaced when every page was fetched
+	defer func() {
+		if err != nil {
+			s.abortDataSetWriter(writer)
+			return
+		}
+
+		err = s.commitDataSetWriter(writer, info)
+	}()
 
 	searchResult, err := s.meliClient.SearchItems(site, query, offset, limit)
 
 	if err != nil {
 		s.logger.Warning("[fetchRandomItemsByCategory] Error searching items.")
-		return
+		return err
 	}
 
+	info.CategoryPath = searchResult.CategoryPath()
+	info.CategoryNames = searchResult.CategoryNames()
+
 	// Save first DataSet
-	s.saveDataSet(searchResult.Results, categoryId, 0)
+	if err := s.saveDataSetPage(writer, info, deduplicator, searchResult.Results, 0); err != nil {
+		return err
+	}
+
+	progress.pageFetched(categoryId, info.Pages, info.Items)
 
 	// Fetch next items by Systematic Random Sampling
 
 	// Get total sampling
 	totalItems := searchResult.Paging.Total
+	info.TotalItems = totalItems
 	s.logger.Info(fmt.Sprintf("[fetchItemsByCategory][%s] Total Items: %d", categoryId, totalItems))
 
 	// Get sample size
-	sampleSize := util.CalcSampleSizeMethod2(totalItems)
+	sampleSize := util.CalcSampleSize(totalItems, s.config.Sampling.Confidence, s.config.Sampling.Precision)
+	info.SampleSize = sampleSize
 	s.logger.Info(fmt.Sprintf("[fetchItemsByCategory][%s] Sample Size: %d", categoryId, sampleSize))
 
-	// Calc P elements p = N / n where N is total items and n is sample size
+	if sampleSize <= 0 {
+		return errors.New(fmt.Sprintf("Sample size: %d of %d items is not positive.", sampleSize, totalItems))
+	}
+
+	// Calc P elements p = N / n where N is total items and n is sample size,
+	// at least 1 so the offsets move forward when the sample is every item
 	p := totalItems / sampleSize
+	if p < 1 {
+		p = 1
+	}
+	info.Proportion = p
 	s.logger.Info(fmt.Sprintf("[fetchItemsByCategory][%s] Proportion of elements p: %d", categoryId, p))
 
 	// Calc K, where offsetK is random offset to start.
-	offsetK := util.GetRandomNumberFrom(p)
+	offsetK := s.rand.Intn(p)
+	info.InitialOffset = offsetK
 	s.logger.Info(fmt.Sprintf("[fetchItemsByCategory][%s] Initial offset: %d", categoryId, offsetK))
 
 	i := 0
@@ -253,155 +587,241 @@ func (s *Suggester) FetchItemsBySystematicRandomSampling(site string, categoryId
 		if err != nil {
 			s.logger.Warning(fmt.Sprintf("[searchItemsByCategory] Error searching items for category: %s", categoryId))
 			s.logger.Debug(err)
-			return
+			return err
 		}
 
 		// Workaround when results is empty
 		if len(searchResult.Results) == 0 {
 			s.logger.Debug("[searchItemsByCategory] Results is empty.")
-			return
+			info.Pages++
+			info.EmptyPages++
+			return nil
+		}
+
+		if err := s.saveDataSetPage(writer, info, deduplicator, searchResult.Results, nextOffsetK); err != nil {
+			return err
 		}
 
-		s.saveDataSet(searchResult.Results, categoryId, nextOffsetK)
+		progress.pageFetched(categoryId, info.Pages, info.Items)
 	}
+
+	if s.deduplicateOnFetch {
+		s.logger.Info(fmt.Sprintf("[fetchItemsByCategory][%s] Duplicates skipped: %d", categoryId, deduplicator.Duplicates))
+	}
+
+	return nil
+}
+
+// Config returns the configuration of the suggester.
+func (s *Suggester) Config() Config {
+	return s.config
+}
+
+// SetDataSetFormat sets the format fetched items are saved with: json (default) or jsonl.gz
+func (s *Suggester) SetDataSetFormat(format string) {
+	s.dataSetFormat = format
+}
+
+// SetDeduplicateOnFetch enables skipping items already fetched for the category in the same fetch.
+func (s *Suggester) SetDeduplicateOnFetch(deduplicateOnFetch bool) {
+	s.deduplicateOnFetch = deduplicateOnFetch
 }
 
-// Clean removes data set and data trained folders.
-func (s *Suggester) Clean() {
+func (s *Suggester) fetchedItems(deduplicator *itemDeduplicator, searchItems []meli.SearchItem) []meli.SearchItem {
+	if !s.deduplicateOnFetch {
+		return searchItems
+	}
+
+	items := make([]meli.SearchItem, 0, len(searchItems))
+
+	for _, item := range searchItems {
+		if duplicate, _ := deduplicator.check(item); !duplicate {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// Close closes the storage.
+func (s *Suggester) Close() error {
+	return s.storage.Close()
+}
+
+// DataSetCategories returns the categories with a data set in storage.
+func (s *Suggester) DataSetCategories() ([]string, error) {
+	return s.storage.DataSetCategories()
+}
+
+// QueryItems returns the items of a category snapshot within a price range.
+func (s *Suggester) QueryItems(query ItemQuery) ([]meli.SearchItem, error) {
+	return QueryItems(s.storage, query)
+}
+
+// Clean removes data set and data trained files.
+func (s *Suggester) Clean() error {
 	s.logger.Info("[Clean] Cleaning data..")
-	s.cleanDirectory(DATA_SET_PATH)
-	s.cleanDirectory(DATA_TRAINED_PATH)
+	err := s.storage.Clean()
+	if err != nil {
+		s.logger.Warning(err)
+		return err
+	}
 	s.logger.Info("[Clean] Done.")
+	return nil
 }
 
-func (s *Suggester) cleanDirectory(path string) {
-	err := os.RemoveAll(path)
+func (s *Suggester) saveDataSet(writer SnapshotWriter, searchItems []meli.SearchItem, index int) error {
+	err := writer.WritePage(searchItems, index)
 	if err != nil {
-		s.logger.Warning(err)
+		s.logger.Warning("[saveDataSet] Error saving dataset.")
+		s.logger.Debug(err)
 	}
+	return err
 }
 
-func (s *Suggester) saveDataSet(searchItems []meli.SearchItem, categoryId string, index int) {
+// saveDataSetPage saves a page of a fetch and counts it in the snapshot info.
+func (s *Suggester) saveDataSetPage(writer SnapshotWriter, info *SnapshotInfo, deduplicator *itemDeduplicator, searchItems []meli.SearchItem, index int) error {
+	info.Pages++
+
+	if len(searchItems) == 0 {
+		info.EmptyPages++
+	}
 
-	itemJson, _ := json.Marshal(searchItems)
+	items := s.fetchedItems(deduplicator, searchItems)
+	info.Items += len(items)
 
-	fileDest := fmt.Sprintf("%s/%s/%s-%d.json", DATA_SET_PATH, categoryId, categoryId, index)
-	err := ioutil.WriteFile(fileDest, itemJson, 0777)
+	return s.saveDataSet(writer, items, index)
+}
+
+func (s *Suggester) commitDataSetWriter(writer SnapshotWriter, info *SnapshotInfo) error {
+	err := writer.Commit(*info)
 	if err != nil {
-		s.logger.Warning("[saveDataSet] Error saving dataset.")
+		s.logger.Warning("[saveDataSet] Error saving snapshot.")
 		s.logger.Debug(err)
 	}
+	return err
 }
 
-func createFolder(path string) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		os.Mkdir(path, 0777)
+func (s *Suggester) abortDataSetWriter(writer SnapshotWriter) {
+	if err := writer.Abort(); err != nil {
+		s.logger.Warning("[saveDataSet] Error discarding snapshot.")
+		s.logger.Debug(err)
 	}
 }
 
-func (s *Suggester) trainModel(dataTrained *DataTrained, outPutItemChannel <-chan *meli.SearchItem, wg *sync.WaitGroup) {
-
-	var dataTrain CategoryPriceTrained
+func (s *Suggester) trainModel(foldersTrained *foldersTrained, outPutItemChannel <-chan *dataSetItem, wg *sync.WaitGroup) {
 
 	// Iterate while outPutItemChannel is open
 	for itemInfo := range outPutItemChannel {
 		s.logger.Debug(fmt.Sprintf("[trainModel] Item: %s", itemInfo.Id))
 
-		categoryId := itemInfo.CategoryId
-
-		dataTrained.RLock()
-		value, exists := dataTrained.data[categoryId]
-		dataTrained.RUnlock()
-
-		if exists {
-			max := value.Max
-			min := value.Min
-
-			if itemInfo.Price > value.Max {
-				max = itemInfo.Price
-			} else if itemInfo.Price < value.Min {
-				min = itemInfo.Price
-			}
-
-			sum := value.Sum + itemInfo.Price
-			total := value.Total + 1
-			suggested := sum / total
-
-			dataTrain = CategoryPriceTrained{
-				Max:       max,
-				Min:       min,
-				Sum:       sum,
-				Total:     total,
-				Suggested: suggested,
-			}
-
-		} else {
-			dataTrain = CategoryPriceTrained{
-				Max:       itemInfo.Price,
-				Min:       itemInfo.Price,
-				Sum:       itemInfo.Price,
-				Total:     1,
-				Suggested: itemInfo.Price,
-			}
-		}
-
-		dataTrained.Lock()
-		dataTrained.data[categoryId] = dataTrain
-		dataTrained.Unlock()
+		foldersTrained.add(itemInfo)
 	}
 
 	wg.Done()
 	s.logger.Debug("[trainModel] Done.")
 }
 
-func (s *Suggester) readItemFilesForCategory(categoryId string, outPutItemChannel chan<- *meli.SearchItem, wg *sync.WaitGroup) {
-
-	categoryDataSetPath := DATA_SET_PATH + categoryId
-
-	s.logger.Debug(fmt.Sprintf("[readCategory:%s] Reading dataset from: %s", categoryId, categoryDataSetPath))
+// addPriceToCategoryTrained returns the category statistics updated with a new item price.
+func addPriceToCategoryTrained(value CategoryPriceTrained, exists bool, price float64) CategoryPriceTrained {
+	if !exists {
+		return CategoryPriceTrained{
+			Max:       price,
+			Min:       price,
+			Sum:       price,
+			Total:     1,
+			Suggested: price,
+		}
+	}
 
-	dataSetFiles, err := ioutil.ReadDir(categoryDataSetPath)
+	max := value.Max
+	min := value.Min
 
-	if err != nil {
-		s.logger.Warning("[readCategory:%s] Error reading dataset: %s", categoryId, categoryDataSetPath)
-		s.logger.Debug(err)
-		return
+	if price > value.Max {
+		max = price
+	} else if price < value.Min {
+		min = price
 	}
 
-	for _, file := range dataSetFiles {
-		if !file.IsDir() {
-			filePath := categoryDataSetPath + "/" + file.Name()
+	sum := value.Sum + price
+	total := value.Total + 1
 
-			s.readItemFileForCategory(categoryId, filePath, outPutItemChannel)
-		}
+	return CategoryPriceTrained{
+		Max:       max,
+		Min:       min,
+		Sum:       sum,
+		Total:     total,
+		Suggested: sum / total,
 	}
-
-	wg.Done()
 }
 
-func (s *Suggester) readItemFileForCategory(categoryId string, filePath string, outPutItemChannel chan<- *meli.SearchItem) {
+// readItemFilesForCategory sends the items of the latest snapshot of a data
+// set folder, recording what was read in report if not nil and reporting it to progress.
+func (s *Suggester) readItemFilesForCategory(categoryId string, report *CategoryTrainReport, progress *progress, outPutItemChannel chan<- *dataSetItem, wg *sync.WaitGroup) {
+
+	defer wg.Done()
 
-	var items []meli.SearchItem
+	if report == nil {
+		report = newCategoryTrainReport()
+	}
 
-	s.logger.Debug(fmt.Sprintf("[readItemCategory:%s] Reading file: %s", categoryId, filePath))
+	progress.categoryStarted(categoryId)
+	defer func() {
+		progress.categoryFinished(categoryId, report.Error)
+	}()
 
-	file, err := ioutil.ReadFile(filePath)
+	// The model is trained with the latest view of the market
+	snapshotDate, ok, err := latestSnapshotDate(s.storage, categoryId)
 
 	if err != nil {
-		s.logger.Warning(fmt.Sprintf("[readItemCategory:%s] Error reading file: %s", categoryId, filePath))
+		s.logger.Warning(fmt.Sprintf("[readCategory:%s] Error reading dataset.", categoryId))
 		s.logger.Debug(err)
+		report.Error = err.Error()
+		return
+	}
+
+	if !ok {
+		s.logger.Debug(fmt.Sprintf("[readCategory:%s] Dataset is empty.", categoryId))
+		return
 	}
 
-	err = json.Unmarshal(file, &items)
+	s.logger.Debug(fmt.Sprintf("[readCategory:%s] Reading snapshot: %s", categoryId, snapshotDate.Format(SNAPSHOT_DATE_LAYOUT)))
+
+	report.SnapshotDate = snapshotDate
+
+	report.UnreadableFiles, err = s.readSnapshot(categoryId, snapshotDate, func(items []meli.SearchItem) {
+		report.Items += len(items)
+		progress.itemsRead(categoryId, report.Items)
+
+		for index, item := range items {
+			s.logger.Debug(fmt.Sprintf("[readItemFile] Sending index: %d  item: %s", index, item.Id))
+			outPutItemChannel <- &dataSetItem{SearchItem: item, Folder: categoryId, SnapshotDate: snapshotDate}
+		}
+	})
 
 	if err != nil {
-		s.logger.Warning(fmt.Sprintf("[readItemCategory:%s] Error Unmarshal file: %s", categoryId, filePath))
+		s.logger.Warning(fmt.Sprintf("[readCategory:%s] Error reading dataset.", categoryId))
 		s.logger.Debug(err)
+		report.Error = err.Error()
 	}
+}
 
-	for index, item := range items {
-		s.logger.Debug(fmt.Sprintf("[readItemFile] Sending index: %d  item: %s", index, item.Id))
-		itemToSend := item
-		outPutItemChannel <- &itemToSend
-	}
+// readSnapshot calls read with the items of each source of a snapshot, and
+// returns the unreadable ones.
+func (s *Suggester) readSnapshot(categoryId string, date time.Time, read func(items []meli.SearchItem)) ([]UnreadableFile, error) {
+	var unreadable []UnreadableFile
+
+	err := s.storage.ReadSnapshot(categoryId, date, func(source string, items []meli.SearchItem, err error) {
+		s.logger.Debug(fmt.Sprintf("[readItemCategory:%s] Reading file: %s", categoryId, source))
+
+		if err != nil {
+			s.logger.Warning(fmt.Sprintf("[readItemCategory:%s] Error reading file: %s", categoryId, source))
+			s.logger.Debug(err)
+			unreadable = append(unreadable, UnreadableFile{Source: source, Error: err.Error()})
+		}
+
+		read(items)
+	})
+
+	return unreadable, err
 }
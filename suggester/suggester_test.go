@@ -1,20 +1,41 @@
 package suggester
 
 import (
+	"fmt"
 	"github.com/jesusfar/meli.price.suggester/meli"
 	"github.com/jesusfar/meli.price.suggester/mock"
+	"github.com/jesusfar/meli.price.suggester/util"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 const checkMark = "\u2713"
 
+// newTestConfig returns the default config with the data folders in a temporary
+// folder, and a function removing it.
+func newTestConfig() (Config, func()) {
+	folder, _ := ioutil.TempDir("", "suggester")
+
+	config := DefaultConfig()
+	config.DataSetPath = filepath.Join(folder, "dataset")
+	config.DataTrainedPath = filepath.Join(folder, "datatrained")
+	config.BoltFile = filepath.Join(folder, "suggester.db")
+
+	return config, func() { os.RemoveAll(folder) }
+}
+
 func TestNewSuggester(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
 
-	suggester := NewSuggester()
+	suggester := NewSuggester(config)
 	t.Log("NewSuggester returns a Suggester pointer.", checkMark)
 	assert.NotNil(t, suggester)
 	assert.IsType(t, &Suggester{}, suggester)
@@ -22,18 +43,29 @@ func TestNewSuggester(t *testing.T) {
 }
 
 func TestSuggester_FetchDataSet(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
 
 	mockServer := httptest.NewServer(http.HandlerFunc(mock.GetCategoriesMock))
 	defer mockServer.Close()
 
-	os.Setenv("MELI_ENDPOINT", mockServer.URL)
+	config.Endpoint = mockServer.URL
 
-	suggester := NewSuggester()
+	suggester := NewSuggester(config)
 
-	suggester.FetchDataSet(meli.SITE_MLA)
+	err := suggester.FetchDataSet(meli.SITE_MLA)
+
+	// The categories mock does not answer searches, so every category fails
+	t.Log("Given categories that fail to fetch, FetchDataSet returns them as CategoryErrors.", checkMark)
+	if assert.IsType(t, CategoryErrors{}, err) {
+		assert.Len(t, err.(CategoryErrors), 30)
+		assert.Equal(t, FETCH_DATA_SET, err.(CategoryErrors)[0].Op)
+	}
 }
 
 func TestSuggester_SetInMemoryDataTrained(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
 
 	// Prepare data trained for test
 	dataTrainedTest = make(map[string]CategoryPriceTrained)
@@ -43,7 +75,7 @@ func TestSuggester_SetInMemoryDataTrained(t *testing.T) {
 		Min:       60,
 	}
 
-	s := NewSuggester()
+	s := NewSuggester(config)
 
 	s.SetInMemoryDataTrained(dataTrainedTest)
 
@@ -54,31 +86,147 @@ func TestSuggester_SetInMemoryDataTrained(t *testing.T) {
 }
 
 func TestNewSuggester_fetchItemsBySystematicRandomSampling(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
 	categoryId := "MLA1050"
 	mockServer := httptest.NewServer(http.HandlerFunc(mock.SearchItemsMock))
 	defer mockServer.Close()
 
-	os.Setenv("MELI_ENDPOINT", mockServer.URL)
+	config.Endpoint = mockServer.URL
+
+	suggester := NewSuggester(config)
+
+	err := suggester.FetchItemsBySystematicRandomSampling(meli.SITE_MLA, categoryId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, true, directoryExists(filepath.Join(config.DataSetPath, categoryId)))
+}
+
+// meliClientTest answers searches with a page of items out of total, and fails
+// the searches after failAfter when set.
+type meliClientTest struct {
+	meli.MeliClient
+	total     int
+	failAfter int
+	searches  int
+}
+
+func (m *meliClientTest) SearchItems(site string, query string, offset int, limit int) (*meli.SearchItemsResult, error) {
+	if m.searches++; m.failAfter > 0 && m.searches > m.failAfter {
+		return nil, meli.MeliClientErr{Message: "Search failed."}
+	}
+
+	result := &meli.SearchItemsResult{Paging: meli.PageInfo{Total: m.total, Offset: offset}}
+
+	if offset < m.total {
+		result.Results = []meli.SearchItem{{Id: fmt.Sprintf("MLA%d", offset), Price: float64(offset + 1), CategoryId: CategoryIdTest}}
+	}
+
+	return result, nil
+}
+
+func TestSuggester_FetchFailure(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	client := &meliClientTest{total: 100}
+	fetchDate := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSuggester(config, WithMeliClient(client), WithClock(func() time.Time { return fetchDate }))
+
+	assert.Nil(t, s.FetchItemsBySystematicRandomSampling(meli.SITE_MLA, CategoryIdTest))
+	s.Train()
+	suggested, _ := s.Suggest(CategoryIdTest)
+
+	client.searches, client.failAfter = 0, 2
+	err := s.FetchItemsBySystematicRandomSampling(meli.SITE_MLA, CategoryIdTest)
+	s.Train()
+	refetched, suggestErr := s.Suggest(CategoryIdTest)
 
-	suggester := NewSuggester()
+	t.Log("Given a fetch of the same day that fails, the snapshot fetched before is still trained.", checkMark)
+	assert.NotNil(t, err)
+	assert.Nil(t, suggestErr)
+	assert.Equal(t, suggested, refetched)
+}
+
+func TestSuggester_FetchNoItems(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
 
-	suggester.FetchItemsBySystematicRandomSampling(meli.SITE_MLA, categoryId)
+	s := NewSuggester(config, WithMeliClient(&meliClientTest{}))
 
-	assert.Equal(t, true, directoryExists(DATA_SET_PATH+"/"+categoryId))
+	err := s.FetchItemsBySystematicRandomSampling(meli.SITE_MLA, CategoryIdTest)
+	categories, _ := s.storage.DataSetCategories()
+
+	t.Log("Given a category without items, the fetch fails with no snapshot saved.", checkMark)
+	assert.NotNil(t, err)
+	assert.Empty(t, categories)
 }
 
 func TestSuggester_Train(t *testing.T) {
-	suggester := NewSuggester()
-	suggester.Train()
-	assert.Equal(t, true, directoryExists(DATA_TRAINED_PATH))
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	suggester := NewSuggester(config)
+	err := suggester.Train()
+	assert.Nil(t, err)
+	assert.Equal(t, true, directoryExists(config.DataTrainedPath))
+}
+
+func TestNewSuggester_Options(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	mockServer := httptest.NewServer(http.HandlerFunc(mock.SearchItemsMock))
+	defer mockServer.Close()
+
+	meliClient := meli.NewMeliHttpClient()
+	meliClient.SetEndpoint(mockServer.URL)
+
+	fetchDate := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	s := NewSuggester(config,
+		WithMeliClient(meliClient),
+		WithLogger(util.NewLogger()),
+		WithClock(func() time.Time { return fetchDate }),
+		WithRand(rand.New(rand.NewSource(1))),
+	)
+
+	t.Log("Given options, NewSuggester does no I/O.", checkMark)
+	assert.False(t, directoryExists(config.DataSetPath))
+
+	_, err := s.Suggest(CategoryIdTest)
+
+	t.Log("Given a model not loaded, Suggest returns error.", checkMark)
+	assert.NotNil(t, err)
+
+	s.FetchItemsBySystematicRandomSampling(meli.SITE_MLA, CategoryIdTest)
+
+	t.Log("Given a clock, the fetched snapshot is dated by it.", checkMark)
+	assert.True(t, directoryExists(filepath.Join(config.DataSetPath, CategoryIdTest, "2018-06-01")))
+
+	s.Train()
+
+	loaded := NewSuggester(config)
+	err = loaded.LoadModel()
+
+	t.Log("Given a model trained, LoadModel loads it.", checkMark)
+	assert.Nil(t, err)
+	assert.NotNil(t, loaded.GetInMemoryDataTrained())
 }
 
 func TestSuggester_LoadDataTrained(t *testing.T) {
-	suggester := NewSuggester()
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	suggester := NewSuggester(config)
 	suggester.LoadDataTrained()
 }
 
 func TestSuggester_Suggest(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
 	// Prepare data trained for test
 	dataTrainedTest = make(map[string]CategoryPriceTrained)
 	dataTrainedTest[CategoryIdTest] = CategoryPriceTrained{
@@ -89,7 +237,7 @@ func TestSuggester_Suggest(t *testing.T) {
 
 	expectedResult := CategoryPriceSuggested{Max: 100, Suggested: 90, Min: 60}
 
-	s := NewSuggester()
+	s := NewSuggester(config)
 
 	s.SetInMemoryDataTrained(dataTrainedTest)
 
@@ -103,11 +251,14 @@ func TestSuggester_Suggest(t *testing.T) {
 }
 
 func TestSuggester_Clean(t *testing.T) {
-	suggester := NewSuggester()
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	suggester := NewSuggester(config)
 	suggester.Clean()
 
-	assert.Equal(t, false, directoryExists(DATA_SET_PATH))
-	assert.Equal(t, false, directoryExists(DATA_TRAINED_PATH))
+	assert.Equal(t, false, directoryExists(config.DataSetPath))
+	assert.Equal(t, false, directoryExists(config.DataTrainedPath))
 }
 
 func directoryExists(path string) bool {
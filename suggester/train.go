@@ -0,0 +1,380 @@
+package suggester
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TrainOptions selects the data set folders to train.
+type TrainOptions struct {
+	// Categories restricts the training to these data set folders.
+	Categories []string
+	// Incremental trains only the data set folders changed since the last training.
+	Incremental bool
+	// DropNearDuplicates skips listings with the same seller and title of an item already trained.
+	DropNearDuplicates bool
+	// Attribution is the policy of the categories an item is trained into,
+	// the configured one if empty. See ATTRIBUTION_FOLDER.
+	Attribution string
+	// Thresholds, when set, refuse to save a model trained out of them.
+	Thresholds *TrainThresholds
+}
+
+// TrainManifest keeps the statistics trained per data set folder, so folders
+// can be retrained and merged into the model without touching the others.
+type TrainManifest struct {
+	Attribution string                   `json:"attribution"`
+	Folders     map[string]FolderTrained `json:"folders"`
+}
+
+// FolderTrained is the contribution of a data set folder to the model.
+type FolderTrained struct {
+	Hash           string                          `json:"hash"`
+	TrainedAt      time.Time                       `json:"trained_at"`
+	Duplicates     int                             `json:"duplicates"`
+	NearDuplicates int                             `json:"near_duplicates"`
+	Categories     map[string]CategoryPriceTrained `json:"categories"`
+	History        map[string][]PricePoint         `json:"history"`
+	// Items are the items trained from the folder, once whatever categories they are trained into.
+	Items int `json:"items"`
+	// CategoryPath is the categories from the root to the folder category.
+	CategoryPath []string `json:"category_path,omitempty"`
+	// CategoryNames are the names of the categories of CategoryPath by id.
+	CategoryNames map[string]string `json:"category_names,omitempty"`
+}
+
+// foldersTrained collects the statistics of the items read per data set folder.
+// Overlapping sampled pages repeat items, so items are deduplicated per folder.
+type foldersTrained struct {
+	sync.Mutex
+	data               map[string]map[string]CategoryPriceTrained
+	deduplicators      map[string]*itemDeduplicator
+	dropNearDuplicates bool
+	attribution        attribution
+}
+
+func newFoldersTrained(dropNearDuplicates bool, attribution attribution) *foldersTrained {
+	return &foldersTrained{
+		data:               make(map[string]map[string]CategoryPriceTrained),
+		deduplicators:      make(map[string]*itemDeduplicator),
+		dropNearDuplicates: dropNearDuplicates,
+		attribution:        attribution,
+	}
+}
+
+func (f *foldersTrained) deduplicator(folder string) *itemDeduplicator {
+	deduplicator, ok := f.deduplicators[folder]
+
+	if !ok {
+		deduplicator = newItemDeduplicator()
+		f.deduplicators[folder] = deduplicator
+	}
+
+	return deduplicator
+}
+
+func (f *foldersTrained) add(item *dataSetItem) {
+	f.Lock()
+	defer f.Unlock()
+
+	duplicate, nearDuplicate := f.deduplicator(item.Folder).check(item.SearchItem)
+
+	if duplicate || (nearDuplicate && f.dropNearDuplicates) {
+		return
+	}
+
+	categories, ok := f.data[item.Folder]
+
+	if !ok {
+		categories = make(map[string]CategoryPriceTrained)
+		f.data[item.Folder] = categories
+	}
+
+	for _, categoryId := range f.attribution.categories(item.Folder, item.CategoryId) {
+		value, exists := categories[categoryId]
+
+		dataTrain := addPriceToCategoryTrained(value, exists, item.Price)
+
+		// Keep the date of the most recent snapshot the category was trained with
+		dataTrain.SnapshotDate = value.SnapshotDate
+		if item.SnapshotDate.After(dataTrain.SnapshotDate) {
+			dataTrain.SnapshotDate = item.SnapshotDate
+		}
+
+		categories[categoryId] = dataTrain
+	}
+}
+
+// DataTrained merges the statistics of the folders of each category in the model.
+func (m TrainManifest) DataTrained() map[string]CategoryPriceTrained {
+	dataTrained := make(map[string]CategoryPriceTrained)
+
+	categoryFolders := m.categoryFolders(func(folder FolderTrained) []string {
+		categories := make([]string, 0, len(folder.Categories))
+		for categoryId := range folder.Categories {
+			categories = append(categories, categoryId)
+		}
+		return categories
+	})
+
+	for categoryId, folders := range categoryFolders {
+		for index, name := range folders {
+			trained := m.Folders[name].Categories[categoryId]
+			if index > 0 {
+				trained = mergeCategoryPriceTrained(dataTrained[categoryId], trained)
+			}
+			dataTrained[categoryId] = trained
+		}
+	}
+
+	return dataTrained
+}
+
+// Items returns the items trained from every folder.
+func (m TrainManifest) Items() int {
+	var items int
+
+	for _, folder := range m.Folders {
+		items += folder.Items
+	}
+
+	return items
+}
+
+// categoryFolders returns, per category, the folders its statistics are merged
+// from. A category with its own data set folder is trained from that folder
+// alone. Otherwise it is merged from the outermost folders trained into it, as
+// the items of a nested folder were already sampled by the folders above it.
+func (m TrainManifest) categoryFolders(categories func(folder FolderTrained) []string) map[string][]string {
+	categoryFolders := make(map[string][]string)
+
+	for name, folder := range m.Folders {
+		for _, categoryId := range categories(folder) {
+			categoryFolders[categoryId] = append(categoryFolders[categoryId], name)
+		}
+	}
+
+	for categoryId, names := range categoryFolders {
+		var folders []string
+
+		for _, name := range names {
+			if name == categoryId {
+				folders = []string{name}
+				break
+			}
+
+			if !m.nestedIn(name, names) {
+				folders = append(folders, name)
+			}
+		}
+
+		sort.Strings(folders)
+		categoryFolders[categoryId] = folders
+	}
+
+	return categoryFolders
+}
+
+// nestedIn reports whether the folder category descends from the category of another of the folders.
+func (m TrainManifest) nestedIn(name string, folders []string) bool {
+	for _, ancestor := range m.Folders[name].CategoryPath {
+		if ancestor == name {
+			continue
+		}
+
+		for _, other := range folders {
+			if other == ancestor {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// PriceHistory merges the price series of the folders of each category.
+func (m TrainManifest) PriceHistory() map[string][]PricePoint {
+	points := make(map[string]map[time.Time]PricePoint)
+
+	categoryFolders := m.categoryFolders(func(folder FolderTrained) []string {
+		categories := make([]string, 0, len(folder.History))
+		for categoryId := range folder.History {
+			categories = append(categories, categoryId)
+		}
+		return categories
+	})
+
+	for categoryId, folders := range categoryFolders {
+		points[categoryId] = make(map[time.Time]PricePoint)
+
+		for _, name := range folders {
+			for _, point := range m.Folders[name].History[categoryId] {
+				if value, exists := points[categoryId][point.Date]; exists {
+					point = mergePricePoint(value, point)
+				}
+				points[categoryId][point.Date] = point
+			}
+		}
+	}
+
+	priceHistory := make(map[string][]PricePoint)
+
+	for categoryId, pointsByDate := range points {
+		series := make([]PricePoint, 0, len(pointsByDate))
+		for _, point := range pointsByDate {
+			series = append(series, point)
+		}
+		priceHistory[categoryId] = sortPriceSeries(series)
+	}
+
+	return priceHistory
+}
+
+// mergeCategoryPriceTrained merges the statistics of a category trained from two folders.
+func mergeCategoryPriceTrained(value CategoryPriceTrained, other CategoryPriceTrained) CategoryPriceTrained {
+	merged := CategoryPriceTrained{
+		Max:          value.Max,
+		Min:          value.Min,
+		Sum:          value.Sum + other.Sum,
+		Total:        value.Total + other.Total,
+		SnapshotDate: value.SnapshotDate,
+	}
+
+	if other.Max > merged.Max {
+		merged.Max = other.Max
+	}
+
+	if other.Min < merged.Min {
+		merged.Min = other.Min
+	}
+
+	if other.SnapshotDate.After(merged.SnapshotDate) {
+		merged.SnapshotDate = other.SnapshotDate
+	}
+
+	if merged.Total > 0 {
+		merged.Suggested = merged.Sum / merged.Total
+	}
+
+	return merged
+}
+
+// selectFoldersToTrain returns the hash of the latest snapshot of each data set
+// folder to train. Folders that no longer exist are removed from the manifest.
+func (s *Suggester) selectFoldersToTrain(dataSetCategories []string, manifest TrainManifest, options TrainOptions) map[string]string {
+	selected := make(map[string]string)
+	existing := make(map[string]bool)
+
+	requested := make(map[string]bool)
+	for _, categoryId := range options.Categories {
+		requested[categoryId] = true
+	}
+
+	for _, categoryId := range dataSetCategories {
+		existing[categoryId] = true
+
+		if len(requested) > 0 && !requested[categoryId] {
+			continue
+		}
+
+		hash, err := hashLatestSnapshot(s.storage, categoryId)
+
+		if err != nil {
+			s.logger.Warning(fmt.Sprintf("[Train] Error hashing dataset for category: %s", categoryId))
+			s.logger.Debug(err)
+		}
+
+		if options.Incremental {
+			if trained, ok := manifest.Folders[categoryId]; ok && trained.Hash == hash {
+				s.logger.Debug("[Train] Dataset not changed for category: " + categoryId)
+				continue
+			}
+		}
+
+		selected[categoryId] = hash
+	}
+
+	for categoryId := range manifest.Folders {
+		if !existing[categoryId] {
+			s.logger.Info("[Train] Removing category without dataset: " + categoryId)
+			delete(manifest.Folders, categoryId)
+		}
+	}
+
+	for categoryId := range requested {
+		if !existing[categoryId] {
+			s.logger.Warning("[Train] Dataset not found for category: " + categoryId)
+		}
+	}
+
+	return selected
+}
+
+// hashLatestSnapshot returns the date and hash of the latest snapshot of a data set folder.
+func hashLatestSnapshot(storage Storage, categoryId string) (string, error) {
+	snapshotDate, ok, err := latestSnapshotDate(storage, categoryId)
+
+	if err != nil || !ok {
+		return "", err
+	}
+
+	hash, err := storage.SnapshotHash(categoryId, snapshotDate)
+
+	if err != nil {
+		return "", err
+	}
+
+	return snapshotDate.Format(SNAPSHOT_DATE_LAYOUT) + ":" + hash, nil
+}
+
+// loadTrainManifest loads the manifest of the last training. An empty manifest
+// is returned if it does not exist, so every folder is trained.
+func (s *Suggester) loadTrainManifest() TrainManifest {
+	manifest := TrainManifest{Folders: make(map[string]FolderTrained)}
+
+	manifestFile, err := s.storage.ReadTrained(TRAIN_MANIFEST_FILE)
+
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("[loadTrainManifest][Notice] Train manifest file: %s does not exist, training every category.", TRAIN_MANIFEST_FILE))
+		return manifest
+	}
+
+	err = json.Unmarshal(manifestFile, &manifest)
+
+	if err != nil || manifest.Folders == nil {
+		s.logger.Warning(fmt.Sprintf("[loadTrainManifest][Notice] Error Unmarshal file: %s, training every category.", TRAIN_MANIFEST_FILE))
+		s.logger.Debug(err)
+		return TrainManifest{Folders: make(map[string]FolderTrained)}
+	}
+
+	return manifest
+}
+
+func (s *Suggester) saveTrainManifest(manifest TrainManifest) error {
+	manifestForSave, _ := json.Marshal(manifest)
+
+	err := s.storage.WriteTrained(TRAIN_MANIFEST_FILE, manifestForSave)
+
+	if err != nil {
+		s.logger.Warning("[saveTrainManifest] Error writing train manifest.")
+		s.logger.Debug(err)
+	}
+
+	return err
+}
+
+func (s *Suggester) saveTrainReport(report TrainReport) error {
+	reportForSave, _ := json.MarshalIndent(report, "", "  ")
+
+	err := s.storage.WriteTrained(TRAIN_REPORT_FILE, reportForSave)
+
+	if err != nil {
+		s.logger.Warning("[saveTrainReport] Error writing train report.")
+		s.logger.Debug(err)
+	}
+
+	return err
+}
@@ -0,0 +1,137 @@
+package suggester
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DROPPED_DUPLICATE      string = "duplicate"
+	DROPPED_NEAR_DUPLICATE string = "near_duplicate"
+)
+
+// TrainReport describes a training run. It is saved next to the model as TRAIN_REPORT_FILE.
+type TrainReport struct {
+	StartedAt   time.Time `json:"started_at"`
+	Duration    float64   `json:"duration_seconds"`
+	Attribution string    `json:"attribution"`
+	// Categories are the data set folders trained in this run.
+	Categories      map[string]*CategoryTrainReport `json:"categories"`
+	ModelCategories int                             `json:"model_categories"`
+	Items           int                             `json:"items"`
+	Trained         int                             `json:"trained"`
+	Dropped         map[string]int                  `json:"dropped"`
+	UnreadableFiles int                             `json:"unreadable_files"`
+	FailedFolders   int                             `json:"failed_folders"`
+	// Error is why the run did not save a model, if it did not.
+	Error string `json:"error,omitempty"`
+}
+
+// CategoryTrainReport is what a data set folder contributed to a training run.
+type CategoryTrainReport struct {
+	SnapshotDate    time.Time        `json:"snapshot_date"`
+	Items           int              `json:"items"`
+	Trained         int              `json:"trained"`
+	Dropped         map[string]int   `json:"dropped"`
+	UnreadableFiles []UnreadableFile `json:"unreadable_files"`
+	// Error is why the folder could not be read, if it could not.
+	Error string `json:"error,omitempty"`
+	// Stats are the statistics of the folder category in the model.
+	Stats *CategoryPriceTrained `json:"stats,omitempty"`
+}
+
+// UnreadableFile is a data set source skipped because it could not be read or decoded.
+type UnreadableFile struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// TrainThresholds are the limits a training run is accepted within. Negative values disable a limit.
+type TrainThresholds struct {
+	MaxUnreadableFiles int
+	MaxFailedFolders   int
+	// MaxDroppedRatio is the share of the items read that can be dropped.
+	MaxDroppedRatio float64
+}
+
+// ThresholdsError is returned by a training run out of its thresholds, whose model is not saved.
+type ThresholdsError struct {
+	Violations []string
+}
+
+func (e ThresholdsError) Error() string {
+	return "Model not saved, training exceeds thresholds:\n" + strings.Join(e.Violations, "\n")
+}
+
+func newTrainReport(startedAt time.Time, attribution string) TrainReport {
+	return TrainReport{
+		StartedAt:   startedAt,
+		Attribution: attribution,
+		Categories:  make(map[string]*CategoryTrainReport),
+		Dropped:     make(map[string]int),
+	}
+}
+
+func newCategoryTrainReport() *CategoryTrainReport {
+	return &CategoryTrainReport{Dropped: make(map[string]int)}
+}
+
+// summarize adds up the reports of the folders in the totals of the run.
+func (r *TrainReport) summarize() {
+	for _, category := range r.Categories {
+		r.Items += category.Items
+		r.Trained += category.Trained
+		r.UnreadableFiles += len(category.UnreadableFiles)
+
+		for reason, dropped := range category.Dropped {
+			r.Dropped[reason] += dropped
+		}
+
+		if category.Error != "" {
+			r.FailedFolders++
+		}
+	}
+}
+
+// categoryErrors returns the folders that could not be read.
+func (r TrainReport) categoryErrors() CategoryErrors {
+	var categoryErrors CategoryErrors
+
+	for categoryId, category := range r.Categories {
+		if category.Error != "" {
+			categoryErrors = append(categoryErrors, &CategoryError{Op: TRAIN_MODEL, CategoryId: categoryId, Err: errors.New(category.Error)})
+		}
+	}
+
+	return categoryErrors
+}
+
+// DroppedRatio returns the share of the items read that were not trained.
+func (r TrainReport) DroppedRatio() float64 {
+	if r.Items == 0 {
+		return 0
+	}
+
+	return float64(r.Items-r.Trained) / float64(r.Items)
+}
+
+// Violations returns a message per threshold the run exceeds.
+func (r TrainReport) Violations(thresholds TrainThresholds) []string {
+	var violations []string
+
+	if thresholds.MaxUnreadableFiles >= 0 && r.UnreadableFiles > thresholds.MaxUnreadableFiles {
+		violations = append(violations, fmt.Sprintf("%d unreadable files, limit is %d", r.UnreadableFiles, thresholds.MaxUnreadableFiles))
+	}
+
+	if thresholds.MaxFailedFolders >= 0 && r.FailedFolders > thresholds.MaxFailedFolders {
+		violations = append(violations, fmt.Sprintf("%d categories failed to read, limit is %d", r.FailedFolders, thresholds.MaxFailedFolders))
+	}
+
+	if thresholds.MaxDroppedRatio >= 0 && r.DroppedRatio() > thresholds.MaxDroppedRatio {
+		violations = append(violations, fmt.Sprintf("%.2f%% of the items dropped, limit is %.2f%%", r.DroppedRatio()*100, thresholds.MaxDroppedRatio*100))
+	}
+
+	return violations
+}
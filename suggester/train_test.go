@@ -0,0 +1,217 @@
+package suggester
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	CategoryIdTrainTestA string = "MLA999004"
+	CategoryIdTrainTestB string = "MLA999005"
+)
+
+func writeTrainTestDataSet(dataSetPath string, categoryId string, prices ...float64) {
+	var items []meli.SearchItem
+	for index, price := range prices {
+		items = append(items, meli.SearchItem{Id: fmt.Sprintf("MLA%d", index), Price: price, CategoryId: categoryId})
+	}
+
+	snapshotFolder := filepath.Join(dataSetPath, categoryId, "2018-01-01")
+	os.MkdirAll(snapshotFolder, 0777)
+
+	itemsJson, _ := json.Marshal(items)
+	ioutil.WriteFile(snapshotFolder+"/"+categoryId+"-0.json", itemsJson, 0777)
+}
+
+func TestSuggester_TrainWithOptions(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdTrainTestA, 10, 20)
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdTrainTestB, 100, 200)
+
+	s := NewSuggester(config)
+	s.Train()
+
+	suggested, _ := s.Suggest(CategoryIdTrainTestA)
+	assert.Equal(t, 15.0, suggested.Suggested)
+
+	t.Log("Given a category option, only that category is trained.", checkMark)
+	{
+		writeTrainTestDataSet(config.DataSetPath, CategoryIdTrainTestA, 30, 40)
+		writeTrainTestDataSet(config.DataSetPath, CategoryIdTrainTestB, 300, 400)
+
+		s.TrainWithOptions(TrainOptions{Categories: []string{CategoryIdTrainTestB}})
+
+		suggestedA, _ := s.Suggest(CategoryIdTrainTestA)
+		suggestedB, _ := s.Suggest(CategoryIdTrainTestB)
+		assert.Equal(t, 15.0, suggestedA.Suggested)
+		assert.Equal(t, 350.0, suggestedB.Suggested)
+	}
+
+	t.Log("Given incremental option, only changed categories are trained.", checkMark)
+	{
+		trainedAtB := s.loadTrainManifest().Folders[CategoryIdTrainTestB].TrainedAt
+
+		s.TrainWithOptions(TrainOptions{Incremental: true})
+
+		suggestedA, _ := s.Suggest(CategoryIdTrainTestA)
+		assert.Equal(t, 35.0, suggestedA.Suggested)
+		assert.Equal(t, trainedAtB, s.loadTrainManifest().Folders[CategoryIdTrainTestB].TrainedAt)
+	}
+}
+
+// failingReadStorage fails reading the snapshots of categoryId.
+type failingReadStorage struct {
+	Storage
+	categoryId string
+}
+
+func (f failingReadStorage) ReadSnapshot(categoryId string, date time.Time, read func(source string, items []meli.SearchItem, err error)) error {
+	if categoryId == f.categoryId {
+		return errors.New("Read failed.")
+	}
+
+	return f.Storage.ReadSnapshot(categoryId, date, read)
+}
+
+func TestSuggester_TrainKeepsFolderNotRead(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdTrainTestA, 10, 20)
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdTrainTestB, 100, 200)
+
+	NewSuggester(config).Train()
+
+	storage := failingReadStorage{Storage: NewFileStorage(config.DataSetPath, config.DataTrainedPath), categoryId: CategoryIdTrainTestA}
+	s := NewSuggester(config, WithStorage(storage))
+
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdTrainTestA, 30, 40)
+	_, err := s.TrainWithOptions(TrainOptions{Categories: []string{CategoryIdTrainTestA}})
+
+	suggested, suggestErr := s.Suggest(CategoryIdTrainTestA)
+	folder := s.loadTrainManifest().Folders[CategoryIdTrainTestA]
+
+	t.Log("Given a folder retrained that can not be read, its last training is kept.", checkMark)
+	assert.IsType(t, CategoryErrors{}, err)
+	assert.Nil(t, suggestErr)
+	assert.Equal(t, 15.0, suggested.Suggested)
+	assert.NotEmpty(t, folder.Categories)
+}
+
+func TestMergeCategoryPriceTrained(t *testing.T) {
+	merged := mergeCategoryPriceTrained(
+		CategoryPriceTrained{Min: 10, Max: 20, Sum: 30, Total: 2},
+		CategoryPriceTrained{Min: 5, Max: 15, Sum: 15, Total: 1},
+	)
+
+	t.Log("Given the statistics of two folders, mergeCategoryPriceTrained merges them.", checkMark)
+	assert.Equal(t, CategoryPriceTrained{Min: 5, Max: 20, Sum: 45, Total: 3, Suggested: 15}, merged)
+}
+
+func TestTrainManifest_PriceHistory(t *testing.T) {
+	manifest := TrainManifest{Folders: map[string]FolderTrained{
+		"MLA1": {History: map[string][]PricePoint{CategoryIdTest: {{Median: 10, Total: 1}}}},
+		"MLA2": {History: map[string][]PricePoint{CategoryIdTest: {{Median: 40, Total: 3}}}},
+	}}
+
+	priceHistory := manifest.PriceHistory()
+
+	t.Log("Given two folders with the same category and date, PriceHistory weights the medians.", checkMark)
+	if assert.Len(t, priceHistory[CategoryIdTest], 1) {
+		assert.Equal(t, 32.5, priceHistory[CategoryIdTest][0].Median)
+		assert.Equal(t, 4, priceHistory[CategoryIdTest][0].Total)
+	}
+}
+
+func TestFoldersTrained_Add(t *testing.T) {
+	item := &dataSetItem{
+		SearchItem: meli.SearchItem{Id: "MLA1", Title: "Celular", Price: 10, CategoryId: CategoryIdTest, Seller: meli.SearchSeller{Id: 1}},
+		Folder:     CategoryIdTest,
+	}
+	nearDuplicate := &dataSetItem{
+		SearchItem: meli.SearchItem{Id: "MLA2", Title: "Celular", Price: 30, CategoryId: CategoryIdTest, Seller: meli.SearchSeller{Id: 1}},
+		Folder:     CategoryIdTest,
+	}
+
+	trained := newFoldersTrained(false, attribution{policy: ATTRIBUTION_FOLDER})
+	trained.add(item)
+	trained.add(item)
+	trained.add(nearDuplicate)
+
+	t.Log("Given a repeated item, it is trained once and near-duplicates are kept.", checkMark)
+	assert.Equal(t, 2.0, trained.data[CategoryIdTest][CategoryIdTest].Total)
+	assert.Equal(t, 1, trained.deduplicator(CategoryIdTest).Duplicates)
+	assert.Equal(t, 1, trained.deduplicator(CategoryIdTest).NearDuplicates)
+
+	trained = newFoldersTrained(true, attribution{policy: ATTRIBUTION_FOLDER})
+	trained.add(item)
+	trained.add(nearDuplicate)
+
+	t.Log("Given drop near-duplicates, near-duplicates are not trained.", checkMark)
+	assert.Equal(t, 1.0, trained.data[CategoryIdTest][CategoryIdTest].Total)
+}
+
+func TestSuggester_TrainReport(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdTrainTestA, 10, 20)
+	snapshotFolder := filepath.Join(config.DataSetPath, CategoryIdTrainTestA, "2018-01-01")
+	ioutil.WriteFile(snapshotFolder+"/"+CategoryIdTrainTestA+"-50.json", []byte(`[{"id": "MLA0", "price": 10}]`), 0777)
+	ioutil.WriteFile(snapshotFolder+"/"+CategoryIdTrainTestA+"-100.json", []byte("{"), 0777)
+
+	s := NewSuggester(config)
+	report, err := s.TrainWithOptions(TrainOptions{})
+
+	t.Log("Given no folder failed to read, TrainWithOptions returns no error.", checkMark)
+	assert.Nil(t, err)
+
+	t.Log("Given a repeated item and an unreadable file, the report counts them.", checkMark)
+	if assert.Contains(t, report.Categories, CategoryIdTrainTestA) {
+		category := report.Categories[CategoryIdTrainTestA]
+		assert.Equal(t, 3, category.Items)
+		assert.Equal(t, 2, category.Trained)
+		assert.Equal(t, 1, category.Dropped[DROPPED_DUPLICATE])
+		assert.Len(t, category.UnreadableFiles, 1)
+		if assert.NotNil(t, category.Stats) {
+			assert.Equal(t, 15.0, category.Stats.Suggested)
+		}
+	}
+	assert.Equal(t, 1, report.ModelCategories)
+	assert.Equal(t, 1, report.UnreadableFiles)
+
+	saved, err := s.storage.ReadTrained(TRAIN_REPORT_FILE)
+
+	t.Log("The report is saved next to the model.", checkMark)
+	assert.Nil(t, err)
+	assert.Contains(t, string(saved), CategoryIdTrainTestA)
+
+	t.Log("Given thresholds, Violations returns the exceeded ones.", checkMark)
+	assert.Len(t, report.Violations(TrainThresholds{MaxUnreadableFiles: 0, MaxFailedFolders: -1, MaxDroppedRatio: 0.5}), 1)
+	assert.Len(t, report.Violations(TrainThresholds{MaxUnreadableFiles: -1, MaxFailedFolders: -1, MaxDroppedRatio: -1}), 0)
+
+	writeTrainTestDataSet(config.DataSetPath, CategoryIdTrainTestB, 30, 40)
+	model, _ := s.storage.ReadTrained(DATA_TRAINED_FILE)
+
+	report, err = s.TrainWithOptions(TrainOptions{Thresholds: &TrainThresholds{MaxUnreadableFiles: 0, MaxFailedFolders: -1, MaxDroppedRatio: -1}})
+	notSaved, _ := s.storage.ReadTrained(DATA_TRAINED_FILE)
+	_, suggestErr := s.Suggest(CategoryIdTrainTestB)
+	saved, _ = s.storage.ReadTrained(TRAIN_REPORT_FILE)
+
+	t.Log("Given a run exceeding its thresholds, the model is neither saved nor loaded, the report is.", checkMark)
+	assert.IsType(t, ThresholdsError{}, err)
+	assert.Equal(t, model, notSaved)
+	assert.Equal(t, ERR_CATEGORY_NOT_FOUND, ErrorCode(suggestErr))
+	assert.NotEmpty(t, report.Error)
+	assert.Contains(t, string(saved), CategoryIdTrainTestB)
+}
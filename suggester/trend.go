@@ -0,0 +1,212 @@
+package suggester
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"math"
+	"sort"
+	"time"
+)
+
+// DAYS_PER_MONTH is the average length of a month used to express trends as monthly rates.
+const DAYS_PER_MONTH float64 = 365.25 / 12
+
+// PricePoint is the median price of a category in a data set snapshot.
+type PricePoint struct {
+	Date   time.Time `json:"date"`
+	Median float64   `json:"median"`
+	Total  int       `json:"total"`
+}
+
+// CategoryPriceTrend is the price series of a category and its fitted monthly rate of change.
+type CategoryPriceTrend struct {
+	CategoryId  string       `json:"category_id"`
+	Series      []PricePoint `json:"series"`
+	MonthlyRate float64      `json:"monthly_rate"`
+}
+
+// Trend returns the median price series of categoryId and its monthly rate of change.
+func (s *Suggester) Trend(categoryId string) (CategoryPriceTrend, error) {
+	return s.currentModel().trend(categoryId)
+}
+
+func (m model) trend(categoryId string) (CategoryPriceTrend, error) {
+	trend := CategoryPriceTrend{CategoryId: categoryId}
+
+	if m.priceHistory == nil {
+		return trend, newSuggesterError(ERR_MODEL_NOT_LOADED, "Price history not loaded.")
+	}
+
+	series, ok := m.priceHistory[categoryId]
+
+	if !ok {
+		return trend, errCategoryNotFound(categoryId)
+	}
+
+	trend.Series = series
+	trend.MonthlyRate, _ = fitMonthlyRate(series)
+
+	return trend, nil
+}
+
+// LoadPriceHistory loads the price history from file if exist and keep in memory.
+func (s *Suggester) LoadPriceHistory() error {
+	priceHistory, err := s.readPriceHistory()
+
+	if err != nil {
+		return err
+	}
+
+	s.SetInMemoryPriceHistory(priceHistory)
+
+	return nil
+}
+
+func (s *Suggester) readPriceHistory() (map[string][]PricePoint, error) {
+	var priceHistory map[string][]PricePoint
+
+	priceHistoryFile, err := s.storage.ReadTrained(PRICE_HISTORY_FILE)
+
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("[LoadPriceHistory][Notice] Price history file: %s does not exist.", PRICE_HISTORY_FILE))
+		return nil, err
+	}
+
+	err = json.Unmarshal(priceHistoryFile, &priceHistory)
+
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("[LoadPriceHistory][Notice] Error Unmarshal file: %s ", PRICE_HISTORY_FILE))
+		s.logger.Debug(err)
+		return nil, err
+	}
+
+	return priceHistory, nil
+}
+
+// SetInMemoryPriceHistory sets the price history to adjust and show trends with, updated now.
+func (s *Suggester) SetInMemoryPriceHistory(priceHistory map[string][]PricePoint) {
+	version, updatedAt := priceHistoryVersion(priceHistory), s.now()
+
+	s.updateModel(func(m *model) {
+		m.priceHistory, m.priceHistoryVersion, m.priceHistoryAt = priceHistory, version, updatedAt
+	})
+}
+
+// priceHistoryVersion hashes a price history as modelVersion does a model, empty without history.
+func priceHistoryVersion(priceHistory map[string][]PricePoint) string {
+	if priceHistory == nil {
+		return ""
+	}
+
+	historyJson, _ := json.Marshal(priceHistory)
+	sum := sha256.Sum256(historyJson)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// folderPriceHistory builds the median price series per attributed category
+// from every snapshot of a data set folder.
+func (s *Suggester) folderPriceHistory(folder string, attribution attribution) map[string][]PricePoint {
+
+	// Prices by category and snapshot date
+	prices := make(map[string]map[time.Time][]float64)
+
+	snapshotDates, err := s.storage.Snapshots(folder)
+
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("[folderPriceHistory] Error reading snapshots for category: %s", folder))
+		s.logger.Debug(err)
+	}
+
+	for _, date := range snapshotDates {
+		_, err = s.readSnapshot(folder, date, func(items []meli.SearchItem) {
+			for _, item := range items {
+				for _, categoryId := range attribution.categories(folder, item.CategoryId) {
+					if prices[categoryId] == nil {
+						prices[categoryId] = make(map[time.Time][]float64)
+					}
+					prices[categoryId][date] = append(prices[categoryId][date], item.Price)
+				}
+			}
+		})
+
+		if err != nil {
+			s.logger.Warning(fmt.Sprintf("[folderPriceHistory] Error reading snapshot: %s for category: %s", date.Format(SNAPSHOT_DATE_LAYOUT), folder))
+			s.logger.Debug(err)
+		}
+	}
+
+	priceHistory := make(map[string][]PricePoint)
+
+	for categoryId, pricesByDate := range prices {
+		series := make([]PricePoint, 0, len(pricesByDate))
+
+		for date, datePrices := range pricesByDate {
+			series = append(series, PricePoint{Date: date, Median: median(datePrices), Total: len(datePrices)})
+		}
+
+		priceHistory[categoryId] = sortPriceSeries(series)
+	}
+
+	return priceHistory
+}
+
+// mergePricePoint merges points of the same date from different data set
+// folders. Medians can not be merged exactly, so they are weighted by total.
+func mergePricePoint(point PricePoint, other PricePoint) PricePoint {
+	total := point.Total + other.Total
+
+	if total == 0 {
+		return point
+	}
+
+	return PricePoint{
+		Date:   point.Date,
+		Median: (point.Median*float64(point.Total) + other.Median*float64(other.Total)) / float64(total),
+		Total:  total,
+	}
+}
+
+func sortPriceSeries(series []PricePoint) []PricePoint {
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Date.Before(series[j].Date)
+	})
+	return series
+}
+
+// fitMonthlyRate fits an exponential trend to the series by least squares on
+// the log of the median price and returns its monthly rate of change. It is
+// not fitted, false, with fewer than 2 dates with a positive median.
+func fitMonthlyRate(series []PricePoint) (float64, bool) {
+	var points int
+	var sumX, sumY, sumXY, sumXX float64
+
+	for _, point := range series {
+		// Log is undefined for free items
+		if point.Median <= 0 {
+			continue
+		}
+
+		x := point.Date.Sub(series[0].Date).Hours() / 24
+		y := math.Log(point.Median)
+
+		points++
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	denominator := float64(points)*sumXX - sumX*sumX
+
+	if points < 2 || denominator == 0 {
+		return 0, false
+	}
+
+	slopePerDay := (float64(points)*sumXY - sumX*sumY) / denominator
+
+	return math.Exp(slopePerDay*DAYS_PER_MONTH) - 1, true
+}
@@ -0,0 +1,94 @@
+package suggester
+
+import (
+	"encoding/json"
+	"github.com/jesusfar/meli.price.suggester/meli"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const CategoryIdTrendTest string = "MLA999002"
+
+func TestFitMonthlyRate(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// A price growing 10% each month
+	var series []PricePoint
+	for i := 0; i < 4; i++ {
+		series = append(series, PricePoint{
+			Date:   start.Add(time.Duration(float64(i)*DAYS_PER_MONTH*24) * time.Hour),
+			Median: 100 * math.Pow(1.1, float64(i)),
+		})
+	}
+
+	rate, fitted := fitMonthlyRate(series)
+
+	t.Log("Given a price growing 10% monthly, fitMonthlyRate returns 0.1", checkMark)
+	assert.True(t, fitted)
+	assert.InDelta(t, 0.1, rate, 0.0001)
+
+	rate, fitted = fitMonthlyRate(series[:1])
+
+	t.Log("Given a single point, fitMonthlyRate is not fitted.", checkMark)
+	assert.False(t, fitted)
+	assert.Equal(t, 0.0, rate)
+}
+
+func TestSuggester_Trend(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	dates := []string{"2018-01-01", "2018-02-01"}
+
+	for index, date := range dates {
+		snapshotFolder := filepath.Join(config.DataSetPath, CategoryIdTrendTest) + "/" + date
+		os.MkdirAll(snapshotFolder, 0777)
+
+		items := []meli.SearchItem{
+			{Id: "MLA1", Price: float64(100 * (index + 1)), CategoryId: CategoryIdTrendTest},
+			{Id: "MLA2", Price: float64(300 * (index + 1)), CategoryId: CategoryIdTrendTest},
+		}
+		itemsJson, _ := json.Marshal(items)
+		ioutil.WriteFile(snapshotFolder+"/"+CategoryIdTrendTest+"-0.json", itemsJson, 0777)
+	}
+
+	s := NewSuggester(config)
+	s.Train()
+
+	trend, err := s.Trend(CategoryIdTrendTest)
+
+	assert.Nil(t, err)
+	if assert.Len(t, trend.Series, 2) {
+		t.Log("Given two snapshots, Trend returns the median price of each one.", checkMark)
+		assert.Equal(t, 200.0, trend.Series[0].Median)
+		assert.Equal(t, 400.0, trend.Series[1].Median)
+		assert.True(t, trend.MonthlyRate > 0)
+	}
+
+	t.Log("Given two snapshots, the model is trained with the latest one.", checkMark)
+	suggested, err := s.Suggest(CategoryIdTrendTest)
+	assert.Nil(t, err)
+	assert.Equal(t, 400.0, suggested.Suggested)
+
+	t.Log("Given two snapshots, the data trained records the latest snapshot date.", checkMark)
+	snapshotDate := s.GetInMemoryDataTrained().data[CategoryIdTrendTest].SnapshotDate
+	assert.Equal(t, time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC), snapshotDate.UTC())
+}
+
+func TestSuggester_TrendNotFound(t *testing.T) {
+	config, cleanup := newTestConfig()
+	defer cleanup()
+
+	s := NewSuggester(config)
+	s.SetInMemoryPriceHistory(map[string][]PricePoint{})
+
+	_, err := s.Trend("MLA0")
+
+	t.Log("Given an unknown category, Trend returns error.", checkMark)
+	assert.NotNil(t, err)
+}
@@ -51,17 +51,19 @@ func CalcSampleSizeMethod1(total int) int {
 
 func CalcSampleSizeMethod2(total int) int {
 
-	// Security of 99%
-	var Z float64 = 2.58
+	// Security of 99% and presition 5%
+	return CalcSampleSize(total, 2.58, 0.05)
+}
+
+// CalcSampleSize returns the sample size of a population of total for the
+// confidence Z value and the precision d, with a proportion of 50%.
+func CalcSampleSize(total int, Z float64, d float64) int {
 
 	// Proportion 50%
 	var p float64 = 0.5
 
 	var q float64 = 1 - p
 
-	// Presition 5%
-	var d float64 = 0.05
-
 	// Total poblation
 	var N float64 = float64(total)
 